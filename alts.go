@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Alt policies for DKPAltPolicy
+const (
+	altPolicySeparate = "separate" // every character has their own DKP pool
+	altPolicyCombined = "combined" // alts share their main's DKP pool
+)
+
+// sheetDateLayouts are the date formats officers use on the sheets
+var sheetDateLayouts = []string{"1/2/2006", "01/02/2006", "1/2/06", "2006-01-02", "Jan 2, 2006", "January 2, 2006", "2006/01/02"}
+
+// PlayerGroup is a main and the alts that belong to them
+type PlayerGroup struct {
+	main Player
+	alts []Player
+}
+
+func combinedAlts() bool {
+	return strings.ToLower(configuration.DKPAltPolicy) == altPolicyCombined
+}
+
+// parseSheetDate understands the date formats used on the sheets
+func parseSheetDate(date string) (time.Time, bool) {
+	date = strings.TrimSpace(date)
+	for _, layout := range sheetDateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// linkedMains maps alts to their main from characters linked with !iam
+func linkedMains() map[string]string {
+	l := LogInit("linkedMains-alts.go")
+	defer l.End()
+	mains := make(map[string]string)
+	err := store.ForEach(bucketSettings, func(key string, raw json.RawMessage) error {
+		var settings UserSettings
+		if err := json.Unmarshal(raw, &settings); err != nil {
+			return err
+		}
+		for _, character := range settings.Characters {
+			if settings.Main != "" && character != settings.Main {
+				mains[character] = settings.Main
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		l.ErrorF("Unable to read linked characters: %s", err.Error())
+	}
+	return mains
+}
+
+// applyMains fills in who each alt belongs to, the roster's main column wins over linked characters
+func applyMains(players []Player) {
+	mains := linkedMains()
+	for i := range players {
+		if players[i].main == "" {
+			players[i].main = mains[players[i].name]
+		}
+		if players[i].main == players[i].name {
+			players[i].main = ""
+		}
+	}
+}
+
+// groupByMain groups alts under their main, an alt whose main isn't on the roster stands alone
+func groupByMain(players []Player) []PlayerGroup {
+	applyMains(players)
+	index := make(map[string]int)
+	var groups []PlayerGroup
+	for _, player := range players {
+		if player.main == "" {
+			index[player.name] = len(groups)
+			groups = append(groups, PlayerGroup{main: player})
+		}
+	}
+	for _, player := range players {
+		if player.main == "" {
+			continue
+		}
+		i, ok := index[player.main]
+		if !ok {
+			groups = append(groups, PlayerGroup{main: player})
+			continue
+		}
+		groups[i].alts = append(groups[i].alts, player)
+	}
+	return groups
+}
+
+// findGroup returns the group a character is in
+func findGroup(groups []PlayerGroup, name string) (PlayerGroup, bool) {
+	for _, group := range groups {
+		if group.main.name == name {
+			return group, true
+		}
+		for _, alt := range group.alts {
+			if alt.name == name {
+				return group, true
+			}
+		}
+	}
+	return PlayerGroup{}, false
+}
+
+// dkp is the group's pool according to the alt policy
+func (g PlayerGroup) dkp() int {
+	if !combinedAlts() {
+		return g.main.dkp
+	}
+	total := g.main.dkp
+	for _, alt := range g.alts {
+		total += alt.dkp
+	}
+	return total
+}
+
+// raidedInstead returns true if the alt has raided more recently than their main
+func (g PlayerGroup) raidedInstead(alt Player) bool {
+	altRaid, ok := parseSheetDate(alt.lastRaid)
+	if !ok {
+		return false
+	}
+	mainRaid, ok := parseSheetDate(g.main.lastRaid)
+	return !ok || altRaid.After(mainRaid)
+}
+
+func (g PlayerGroup) format() string {
+	response := fmt.Sprintf("%s(%s):\t%d", g.main.name, g.main.rank, g.dkp())
+	if combinedAlts() && len(g.alts) > 0 {
+		response += " combined"
+	}
+	response += "\n"
+	sort.Sort(sort.Reverse(byDKP(g.alts)))
+	for _, alt := range g.alts {
+		response = fmt.Sprintf("%s\t%s(%s alt):\t%d", response, alt.name, alt.rank, alt.dkp)
+		if g.raidedInstead(alt) {
+			response = fmt.Sprintf("%s\t(raided in place of %s on %s)", response, g.main.name, alt.lastRaid)
+		}
+		response += "\n"
+	}
+	return response
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// attendanceAll asks !attendance for everyone on the roster
+const attendanceAll = "all"
+
+// attendanceLifetime asks !attendance for every raid on the summary sheet
+const attendanceLifetime = "lifetime"
+
+// defaultAttendanceDays is the window used when !attendance isn't given one
+const defaultAttendanceDays = 30
+
+// RaidAttendance is who was at each raid on the summary sheet
+type RaidAttendance struct {
+	raids    []time.Time                   // every raid held, oldest first
+	attended map[string]map[time.Time]bool // lower case player to the raids they were at
+}
+
+// AttendanceReport is a player's attendance over a window
+type AttendanceReport struct {
+	name          string
+	attended      int
+	held          int
+	streak        int // raids in a row up to the latest one
+	longestStreak int
+	lastSeen      time.Time
+	eligible      bool // for loot, always judged over defaultAttendanceDays whatever the window
+}
+
+// LookupAttendance reports attendance for a player, class or everyone over 30d, 60d, 90d or lifetime
+func LookupAttendance(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("LookupAttendance-attendance.go")
+	defer l.End()
+	days := defaultAttendanceDays
+	var args []string
+	for _, token := range message[1:] {
+		if n, ok := parseAttendanceWindow(token); ok {
+			days = n
+			continue
+		}
+		args = append(args, token)
+	}
+	arg := strings.Join(args, " ")
+	attendance, err := readRaidAttendance()
+	if err != nil {
+		l.ErrorF("Unable to read attendance: %s", err.Error())
+		return err.Error()
+	}
+	var since time.Time
+	window := attendanceLifetime
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+		window = fmt.Sprintf("last %d days", days)
+	}
+	var names []string
+	switch players := lookupPlayersByClass(arg); {
+	case strings.ToLower(arg) == attendanceAll:
+		for _, player := range lookupAllPlayer() {
+			names = append(names, player.name)
+		}
+	case arg != "" && len(players) > 0:
+		for _, player := range players {
+			names = append(names, player.name)
+		}
+	default:
+		name, err := resolveCharacter(m, arg)
+		if err != nil {
+			return err.Error()
+		}
+		names = append(names, name)
+	}
+	var reports []AttendanceReport
+	for _, name := range names {
+		report := attendance.report(name, since)
+		report.eligible = attendance.eligible(name)
+		reports = append(reports, report)
+	}
+	if len(reports) == 0 || reports[0].held == 0 {
+		return fmt.Sprintf("No raids on the summary sheet in the %s", window)
+	}
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].percent() > reports[j].percent() })
+	response = fmt.Sprintf("Attendance for the %s, %d raids\n", window, reports[0].held)
+	for _, report := range reports {
+		response += report.format()
+	}
+	return response
+}
+
+// parseAttendanceWindow understands 30d, 60d, 90d, any number of days and lifetime, which is 0
+func parseAttendanceWindow(token string) (int, bool) {
+	token = strings.ToLower(token)
+	if token == attendanceLifetime {
+		return 0, true
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(token, "d"))
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// readRaidAttendance reads the summary sheet, a raid was held on a day someone earned DKP and a
+// player attended it if they earned DKP that day. Loot, decay and other spends don't count
+func readRaidAttendance() (RaidAttendance, error) {
+	attendance := RaidAttendance{attended: make(map[string]map[time.Time]bool)}
+	rows, err := dataSource.Summary()
+	if err != nil {
+		return attendance, err
+	}
+	summary, err := mapColumns(sheetSummary, rows)
+	if err != nil {
+		return attendance, err
+	}
+	held := make(map[time.Time]bool)
+	for _, row := range summary.dataRows(rows) {
+		date, ok := parseSheetDate(summary.cell(row, colDate))
+		player := strings.ToLower(strings.TrimSpace(summary.cell(row, colPlayer)))
+		if !ok || player == "" || isDecayRow(summary.cell(row, colDesc)) {
+			continue
+		}
+		if dkp, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(summary.cell(row, colDKP)), ",", "")); err != nil || dkp <= 0 {
+			continue
+		}
+		if !held[date] {
+			held[date] = true
+			attendance.raids = append(attendance.raids, date)
+		}
+		if attendance.attended[player] == nil {
+			attendance.attended[player] = make(map[time.Time]bool)
+		}
+		attendance.attended[player][date] = true
+	}
+	sort.Slice(attendance.raids, func(i, j int) bool { return attendance.raids[i].Before(attendance.raids[j]) })
+	return attendance, nil
+}
+
+// report works out a player's attendance for raids since the given time
+func (ra RaidAttendance) report(name string, since time.Time) AttendanceReport {
+	report := AttendanceReport{name: name}
+	attended := ra.attended[strings.ToLower(name)]
+	var run int
+	for _, raid := range ra.raids {
+		if raid.Before(since) {
+			continue
+		}
+		report.held++
+		if !attended[raid] {
+			run = 0
+			continue
+		}
+		report.attended++
+		report.lastSeen = raid
+		run++
+		if run > report.longestStreak {
+			report.longestStreak = run
+		}
+	}
+	report.streak = run
+	return report
+}
+
+func (ar AttendanceReport) percent() int {
+	if ar.held == 0 {
+		return 0
+	}
+	return ar.attended * 100 / ar.held
+}
+
+// percent is a player's attendance over the last defaultAttendanceDays, the one measure
+// loot eligibility, auction tie-breaks and !top use
+func (ra RaidAttendance) percent(name string) int {
+	return ra.report(name, time.Now().AddDate(0, 0, -defaultAttendanceDays)).percent()
+}
+
+// eligible returns true if the player meets AttendanceThreshold for loot
+func (ra RaidAttendance) eligible(name string) bool {
+	return ra.percent(name) >= configuration.AttendanceThreshold
+}
+
+func (ar AttendanceReport) format() string {
+	lastSeen := "never"
+	if !ar.lastSeen.IsZero() {
+		lastSeen = ar.lastSeen.Format(sheetDateLayouts[0])
+	}
+	response := fmt.Sprintf("%s:\t%d/%d (%d%%)\tstreak %d, best %d\tlast seen %s", ar.name, ar.attended, ar.held, ar.percent(), ar.streak, ar.longestStreak, lastSeen)
+	if !ar.eligible {
+		response = fmt.Sprintf("%s\t**below %d%%, not loot eligible**", response, configuration.AttendanceThreshold)
+	}
+	return response + "\n"
+}
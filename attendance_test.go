@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestParseAttendanceWindow(t *testing.T) {
+	tests := []struct {
+		token string
+		days  int
+		ok    bool
+	}{
+		{"30d", 30, true},
+		{"60D", 60, true},
+		{"90", 90, true},
+		{"7d", 7, true},
+		{attendanceLifetime, 0, true},
+		{"Lifetime", 0, true},
+		{"0d", 0, false},
+		{"-5d", 0, false},
+		{"d", 0, false},
+		{"month", 0, false},
+	}
+	for _, tt := range tests {
+		days, ok := parseAttendanceWindow(tt.token)
+		if days != tt.days || ok != tt.ok {
+			t.Errorf("parseAttendanceWindow(%q) = %d, %v, want %d, %v", tt.token, days, ok, tt.days, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Bid modes for AuctionBidMode
+const (
+	bidModeOpen = "open" // bids are made and announced in the auction channel
+	bidModeDM   = "dm"   // bids are DMed to the bot and kept sealed until the auction ends
+)
+
+// bidModeSealed is another name for bidModeDM
+const bidModeSealed = "sealed"
+
+// defaultAuctionMinutes is used when neither the command nor AuctionMinutes gives a length
+const defaultAuctionMinutes = 3
+
+// Auction is an item being bid on
+type Auction struct {
+	id        int
+	item      string
+	channelID string
+	startedBy string
+	ends      time.Time
+	bids      []Bid
+	rule      PricingRule
+}
+
+// Bid is a character's offer on an auction, a later bid replaces an earlier one
+type Bid struct {
+	player Player
+	amount int
+	placed time.Time
+}
+
+// auctions are the auctions running right now
+var auctions struct {
+	sync.Mutex
+	running map[int]*Auction
+	nextID  int
+}
+
+func auctionBidMode() string {
+	switch strings.ToLower(configuration.AuctionBidMode) {
+	case bidModeDM, bidModeSealed:
+		return bidModeDM
+	}
+	return bidModeOpen
+}
+
+// StartAuction opens bidding on an item in the current channel
+func StartAuction(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("StartAuction-auction.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Auction command ran without an item: %s", message)
+		return ""
+	}
+	args := message[1:]
+	minutes := configuration.AuctionMinutes
+	if minutes <= 0 {
+		minutes = defaultAuctionMinutes
+	}
+	if len(args) > 1 {
+		if n, err := strconv.Atoi(args[len(args)-1]); err == nil && n > 0 {
+			minutes = n
+			args = args[:len(args)-1]
+		}
+	}
+	rule, err := newPricingRule()
+	if err != nil {
+		l.ErrorF("Unable to start an auction: %s", err.Error())
+		return err.Error()
+	}
+	item := strings.Join(args, " ")
+	auction := &Auction{
+		item:      item,
+		channelID: m.ChannelID,
+		startedBy: m.Author.Username,
+		ends:      time.Now().Add(time.Duration(minutes) * time.Minute),
+		rule:      rule,
+	}
+	auctions.Lock()
+	if auctions.running == nil {
+		auctions.running = make(map[int]*Auction)
+	}
+	auctions.nextID++
+	auction.id = auctions.nextID
+	auctions.running[auction.id] = auction
+	auctions.Unlock()
+	time.AfterFunc(time.Until(auction.ends), func() { closeAuction(s, auction.id) })
+	l.InfoF("%s started auction %d for %s, %d minutes", m.Author.Username, auction.id, item, minutes)
+	where := "in this channel"
+	if auctionBidMode() == bidModeDM {
+		where = "by DM"
+	}
+	return fmt.Sprintf("Auction #%d: %s is open for %d minutes, %s, bid %s with %s %s <dkp>", auction.id, item, minutes, rule.name(), where, configuration.CommBidCommand, item)
+}
+
+// PlaceBid bids the caller's main on a running auction, the item can be left off when
+// only one auction is running
+func PlaceBid(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("PlaceBid-auction.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Bid command ran without an amount: %s", message)
+		return ""
+	}
+	amount, err := strconv.Atoi(message[len(message)-1])
+	if err != nil || amount <= 0 {
+		return fmt.Sprintf("%s is not a DKP amount", message[len(message)-1])
+	}
+	dm := ComesFromDM(s, m)
+	if auctionBidMode() == bidModeDM && !dm {
+		return "Bids are private, DM me your bid"
+	}
+	name, err := resolveCharacter(m, "")
+	if err != nil {
+		return err.Error()
+	}
+	player := lookupPlayer(name)
+	if player.name == "" {
+		return fmt.Sprintf("%s is not on the DKP sheet", name)
+	}
+	auctions.Lock()
+	defer auctions.Unlock()
+	auction, err := findAuction(strings.Join(message[1:len(message)-1], " "))
+	if err != nil {
+		return err.Error()
+	}
+	if committed := committedBids(player.name, auction); amount > player.dkp-committed {
+		if auctionBidMode() == bidModeDM {
+			return fmt.Sprintf("%s doesn't have %d uncommitted DKP", player.name, amount)
+		}
+		if committed > 0 {
+			return fmt.Sprintf("%s only has %d DKP, %d of it is bid on other auctions", player.name, player.dkp, committed)
+		}
+		return fmt.Sprintf("%s only has %d DKP", player.name, player.dkp)
+	}
+	if auctionBidMode() == bidModeOpen {
+		if dm {
+			return fmt.Sprintf("Bids on %s are open, bid in the auction channel", auction.item)
+		}
+		if high := auction.highBid(); amount < high {
+			return fmt.Sprintf("The bid on %s is already %d", auction.item, high)
+		}
+	}
+	bid := Bid{player: player, amount: amount, placed: time.Now()}
+	if err := auction.rule.validate(auction, bid); err != nil {
+		return err.Error()
+	}
+	auction.placeBid(bid)
+	l.InfoF("%s bid %d on auction %d (%s)", player.name, amount, auction.id, auction.item)
+	if auctionBidMode() == bidModeDM {
+		return fmt.Sprintf("Your bid of %d on %s is in, it closes in %v", amount, auction.item, time.Until(auction.ends).Round(time.Second))
+	}
+	return fmt.Sprintf("%s bids %d on %s, closes in %v", player.name, amount, auction.item, time.Until(auction.ends).Round(time.Second))
+}
+
+// findAuction finds a running auction by item, the caller must hold the auctions lock
+func findAuction(item string) (*Auction, error) {
+	if len(auctions.running) == 0 {
+		return nil, errors.New("There are no auctions running")
+	}
+	var matches []*Auction
+	for _, auction := range auctions.running {
+		if item == "" || strings.Contains(strings.ToLower(auction.item), strings.ToLower(item)) || item == "#"+strconv.Itoa(auction.id) {
+			matches = append(matches, auction)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("There is no auction for %s", item)
+	case 1:
+		return matches[0], nil
+	}
+	var names []string
+	for _, auction := range matches {
+		names = append(names, fmt.Sprintf("#%d %s", auction.id, auction.item))
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("Which auction? %s", strings.Join(names, ", "))
+}
+
+// committedBids is how much DKP a character has bid on running auctions other than except.
+// Every bid counts, not just leading ones, so the total says nothing about sealed standings.
+// The caller must hold the auctions lock
+func committedBids(name string, except *Auction) int {
+	var committed int
+	for _, auction := range auctions.running {
+		if auction == except {
+			continue
+		}
+		for _, bid := range auction.bids {
+			if bid.player.name == name {
+				committed += bid.amount
+			}
+		}
+	}
+	return committed
+}
+
+// placeBid replaces the bidder's earlier bid
+func (a *Auction) placeBid(bid Bid) {
+	for i := range a.bids {
+		if a.bids[i].player.name == bid.player.name {
+			a.bids[i] = bid
+			return
+		}
+	}
+	a.bids = append(a.bids, bid)
+}
+
+func (a *Auction) highBid() int {
+	var high int
+	for _, bid := range a.bids {
+		if bid.amount > high {
+			high = bid.amount
+		}
+	}
+	return high
+}
+
+// closeAuction ends an auction and announces the winner in its channel
+func closeAuction(s *discordgo.Session, id int) {
+	l := LogInit("closeAuction-auction.go")
+	defer l.End()
+	auctions.Lock()
+	auction, ok := auctions.running[id]
+	delete(auctions.running, id)
+	auctions.Unlock()
+	if !ok {
+		return
+	}
+	msg := auction.result()
+	l.InfoF("Auction %d closed: %s", id, msg)
+	if _, err := s.ChannelMessageSend(auction.channelID, msg); err != nil {
+		l.ErrorF("Error announcing auction %d: %s", id, err.Error())
+	}
+}
+
+// result picks the winner, ties go to rank, then attendance, then a roll. What the
+// winner pays comes from the auction's pricing rule
+func (a *Auction) result() string {
+	l := LogInit("result-auction.go")
+	defer l.End()
+	if len(a.bids) == 0 {
+		return fmt.Sprintf("Auction #%d: %s closed with no bids", a.id, a.item)
+	}
+	high := a.highBid()
+	var tied []Bid
+	for _, bid := range a.bids {
+		if bid.amount == high {
+			tied = append(tied, bid)
+		}
+	}
+	attendance, err := readRaidAttendance()
+	if err != nil { // nobody has attendance, ties skip straight to the roll
+		l.ErrorF("Unable to read attendance to break ties: %s", err.Error())
+	}
+	winner, reason := breakTie(tied, attendance)
+	response := fmt.Sprintf("Auction #%d: %s wins %s for %d DKP", a.id, winner.player.name, a.item, a.rule.price(a, winner))
+	if reason != "" {
+		response = fmt.Sprintf("%s (%s)", response, reason)
+	}
+	return response
+}
+
+// breakTie picks between equal bids by rank, then attendance, then a roll, returning
+// how the tie was broken
+func breakTie(tied []Bid, attendance RaidAttendance) (Bid, string) {
+	if len(tied) == 1 {
+		return tied[0], ""
+	}
+	tied = bestBids(tied, func(bid Bid) int { return -rankPriority(bid.player.rank) })
+	if len(tied) == 1 {
+		return tied[0], "tie broken by rank"
+	}
+	tied = bestBids(tied, func(bid Bid) int { return attendance.percent(bid.player.name) })
+	if len(tied) == 1 {
+		return tied[0], "tie broken by attendance"
+	}
+	var rolls []string
+	best, bestRoll := 0, 0
+	for i, bid := range tied {
+		roll := rand.Intn(100) + 1
+		for roll == bestRoll { // no ties on the roll
+			roll = rand.Intn(100) + 1
+		}
+		if roll > bestRoll {
+			best, bestRoll = i, roll
+		}
+		rolls = append(rolls, fmt.Sprintf("%s rolled %d", bid.player.name, roll))
+	}
+	return tied[best], "tie broken by a roll: " + strings.Join(rolls, ", ")
+}
+
+// bestBids keeps the bids with the highest score
+func bestBids(bids []Bid, score func(Bid) int) []Bid {
+	var best []Bid
+	for _, bid := range bids {
+		switch {
+		case len(best) == 0 || score(bid) > score(best[0]):
+			best = []Bid{bid}
+		case score(bid) == score(best[0]):
+			best = append(best, bid)
+		}
+	}
+	return best
+}
+
+// rankPriority is the rank's position in AuctionRankPriority, lower wins and unlisted ranks come last
+func rankPriority(rank string) int {
+	for i, r := range configuration.AuctionRankPriority {
+		if strings.EqualFold(r, rank) {
+			return i
+		}
+	}
+	return len(configuration.AuctionRankPriority)
+}
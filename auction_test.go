@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBreakTie(t *testing.T) {
+	recent := func(days int) string { return time.Now().AddDate(0, 0, -days).Format(sheetDateLayouts[0]) }
+	useCSVSheets(t, map[string]string{sheetSummary: fmt.Sprintf("Date,Name,Description,DKP\n"+
+		"%[1]s,Alpha,Raid,5\n%[1]s,Bravo,Raid,5\n%[1]s,Charlie,Raid,5\n%[1]s,Delta,Raid,5\n"+
+		"%[2]s,Alpha,Raid,5\n%[2]s,Charlie,Raid,5\n%[2]s,Delta,Raid,5\n", recent(2), recent(1))})
+	attendance, err := readRaidAttendance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configuration.AuctionRankPriority = []string{"Officer", "Member", "Recruit"}
+	defer func() { configuration.AuctionRankPriority = nil }()
+	bid := func(name, rank string) Bid { return Bid{player: Player{name: name, rank: rank}, amount: 50} }
+	tests := []struct {
+		name    string
+		tied    []Bid
+		winners []string // any of these may win
+		reason  string   // what the reason starts with
+	}{
+		{"no tie", []Bid{bid("Bravo", "Recruit")}, []string{"Bravo"}, ""},
+		{"rank", []Bid{bid("Alpha", "Member"), bid("Bravo", "officer")}, []string{"Bravo"}, "tie broken by rank"},
+		{"unlisted ranks come last", []Bid{bid("Alpha", "Alt"), bid("Bravo", "Recruit")}, []string{"Bravo"}, "tie broken by rank"},
+		{"attendance", []Bid{bid("Bravo", "Member"), bid("Alpha", "Member")}, []string{"Alpha"}, "tie broken by attendance"},
+		{"roll", []Bid{bid("Alpha", "Member"), bid("Charlie", "Member"), bid("Delta", "Member"), bid("Bravo", "Member")}, []string{"Alpha", "Charlie", "Delta"}, "tie broken by a roll: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			winner, reason := breakTie(tt.tied, attendance)
+			if !containsString(tt.winners, winner.player.name) {
+				t.Errorf("%s won, want one of %v", winner.player.name, tt.winners)
+			}
+			if !strings.HasPrefix(reason, tt.reason) || (tt.reason == "" && reason != "") {
+				t.Errorf("reason %q, want %q", reason, tt.reason)
+			}
+		})
+	}
+}
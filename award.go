@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultAwardBatchSeconds is used when AwardBatchSeconds isn't configured
+const defaultAwardBatchSeconds = 30
+
+// bucketAwards holds awards waiting for the summary sheet keyed by id, so a restart
+// inside the batch wait doesn't lose them
+const bucketAwards = "awards"
+
+// LootAward is an item given to a player, waiting to be written to the summary sheet
+type LootAward struct {
+	ID     int       `json:"ID"`
+	Date   time.Time `json:"Date"`
+	Player string    `json:"Player"`
+	Item   string    `json:"Item"`
+	Cost   int       `json:"Cost"`
+}
+
+// SummaryRow is a row for the bottom of the summary sheet
+type SummaryRow struct {
+	date        time.Time
+	player      string
+	description string
+	dkp         int
+}
+
+// pendingAwards batches awards, they're written together once no award has been made for
+// AwardBatchSeconds. The lock is held while flushing so an award is only written once
+var pendingAwards struct {
+	sync.Mutex
+	timer *time.Timer
+}
+
+// AwardItem charges a player for an item and queues it for the summary sheet,
+// "flush" writes the queue now
+func AwardItem(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("AwardItem-award.go")
+	defer l.End()
+	if len(message) == 2 && strings.ToLower(message[1]) == "flush" {
+		count, err := flushAwards()
+		if err != nil {
+			return fmt.Sprintf("Unable to write %d awards to the summary sheet, they'll be tried again", count)
+		}
+		return fmt.Sprintf("Wrote %d awards to the summary sheet", count)
+	}
+	if len(message) < 4 {
+		l.ErrorF("Award command ran without a player, item and cost: %s", message)
+		return fmt.Sprintf("%s <player> <item> <cost>", configuration.CommAwardCommand)
+	}
+	cost, err := strconv.Atoi(message[len(message)-1])
+	if err != nil || cost < 0 {
+		return fmt.Sprintf("%s is not a DKP cost", message[len(message)-1])
+	}
+	player := lookupPlayer(normalizeCharacter(message[1]))
+	if player.name == "" {
+		return fmt.Sprintf("%s is not on the DKP sheet", normalizeCharacter(message[1]))
+	}
+	award := LootAward{Date: time.Now(), Player: player.name, Item: strings.Join(message[2:len(message)-1], " "), Cost: cost}
+	var queued int
+	err = store.Update(func(tx *StoreTx) error { // the ledger and the queue are saved together so they can't disagree
+		if err := putLedgerEntry(tx, &LedgerEntry{Time: award.Date, Kind: ledgerSpend, Character: award.Player, Amount: -award.Cost, Description: award.Item, RecordedBy: m.Author.Username}); err != nil {
+			return err
+		}
+		award.ID = tx.NextID(bucketAwards)
+		if err := tx.Put(bucketAwards, strconv.Itoa(award.ID), award); err != nil {
+			return err
+		}
+		queued = len(tx.data.Buckets[bucketAwards])
+		return nil
+	})
+	if err != nil {
+		l.ErrorF("Error recording the award of %s to %s: %s", award.Item, award.Player, err.Error())
+		return "Unable to record the award, please try again"
+	}
+	pendingAwards.Lock()
+	scheduleAwardFlush()
+	pendingAwards.Unlock()
+	l.InfoF("%s awarded %s to %s for %d", m.Author.Username, award.Item, award.Player, award.Cost)
+	return fmt.Sprintf("%s awarded to %s for %d DKP, %d awards waiting for the summary sheet", award.Item, award.Player, award.Cost, queued)
+}
+
+// resumeAwards schedules a flush for awards queued before a restart
+func resumeAwards() {
+	l := LogInit("resumeAwards-award.go")
+	defer l.End()
+	awards, err := queuedAwards()
+	if err != nil {
+		l.ErrorF("Unable to read queued awards: %s", err.Error())
+		return
+	}
+	if len(awards) == 0 {
+		return
+	}
+	l.InfoF("%d awards are waiting for the summary sheet", len(awards))
+	pendingAwards.Lock()
+	scheduleAwardFlush()
+	pendingAwards.Unlock()
+}
+
+// queuedAwards returns every award waiting for the summary sheet in the order they were made
+func queuedAwards() ([]LootAward, error) {
+	var awards []LootAward
+	err := store.ForEach(bucketAwards, func(key string, raw json.RawMessage) error {
+		var award LootAward
+		if err := json.Unmarshal(raw, &award); err != nil {
+			return err
+		}
+		awards = append(awards, award)
+		return nil
+	})
+	return awards, err
+}
+
+// scheduleAwardFlush restarts the batch timer, the caller must hold the pendingAwards lock
+func scheduleAwardFlush() {
+	wait := time.Duration(configuration.AwardBatchSeconds) * time.Second
+	if wait <= 0 {
+		wait = defaultAwardBatchSeconds * time.Second
+	}
+	if pendingAwards.timer != nil {
+		pendingAwards.timer.Stop()
+	}
+	pendingAwards.timer = time.AfterFunc(wait, func() {
+		l := LogInit("scheduleAwardFlush-award.go")
+		defer l.End()
+		if count, err := flushAwards(); err != nil {
+			l.ErrorF("Unable to write %d awards to the summary sheet: %s", count, err.Error())
+		}
+	})
+}
+
+// flushAwards writes every queued award to the summary sheet in one update, on failure
+// they stay queued and are tried again after the next batch wait
+func flushAwards() (int, error) {
+	l := LogInit("flushAwards-award.go")
+	defer l.End()
+	pendingAwards.Lock()
+	defer pendingAwards.Unlock()
+	awards, err := queuedAwards()
+	if err != nil {
+		l.ErrorF("Unable to read queued awards: %s", err.Error())
+		return 0, err
+	}
+	if len(awards) == 0 {
+		return 0, nil
+	}
+	var entries []SummaryRow
+	for _, award := range awards {
+		entries = append(entries, SummaryRow{date: award.Date, player: award.Player, description: award.Item, dkp: -award.Cost})
+	}
+	rows, err := summaryRows(entries)
+	if err == nil {
+		err = dataSource.AppendSummary(rows)
+	}
+	if err != nil {
+		l.ErrorF("Unable to write awards to the summary sheet: %s", err.Error())
+		scheduleAwardFlush()
+		return len(awards), err
+	}
+	if pendingAwards.timer != nil {
+		pendingAwards.timer.Stop()
+	}
+	err = store.Update(func(tx *StoreTx) error {
+		for _, award := range awards {
+			tx.Delete(bucketAwards, strconv.Itoa(award.ID))
+		}
+		return nil
+	})
+	if err != nil { // they're on the sheet, but will be written again next flush
+		l.ErrorF("Unable to clear %d written awards from the queue: %s", len(awards), err.Error())
+	}
+	l.InfoF("Wrote %d awards to the summary sheet", len(awards))
+	return len(awards), nil
+}
+
+// summaryRows lays entries out in the summary sheet's columns
+func summaryRows(entries []SummaryRow) ([][]interface{}, error) {
+	sheet, err := dataSource.Summary()
+	if err != nil {
+		return nil, err
+	}
+	layout, err := mapColumns(sheetSummary, sheet)
+	if err != nil {
+		return nil, err
+	}
+	width := 0
+	for _, col := range layout.cols {
+		if col+1 > width {
+			width = col + 1
+		}
+	}
+	var rows [][]interface{}
+	for _, entry := range entries {
+		row := make([]interface{}, width)
+		for i := range row {
+			row[i] = ""
+		}
+		row[layout.cols[colDate]] = entry.date.Format(sheetDateLayouts[0])
+		row[layout.cols[colPlayer]] = entry.player
+		row[layout.cols[colDesc]] = entry.description
+		row[layout.cols[colDKP]] = entry.dkp
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
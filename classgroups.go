@@ -0,0 +1,123 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultClassData is classgroups.json, the class groups and aliases every guild gets
+//
+//go:embed classgroups.json
+var defaultClassData []byte
+
+// ClassDefinitions are class groups and the aliases players use for classes. A group can
+// list classes, aliases or other groups
+type ClassDefinitions struct {
+	Groups  map[string][]string `json:"Groups"`
+	Aliases map[string]string   `json:"Aliases"`
+}
+
+// defaultClasses are the definitions in classgroups.json, ClassGroups and ClassAliases add to or replace them
+var defaultClasses ClassDefinitions
+
+func init() {
+	if err := json.Unmarshal(defaultClassData, &defaultClasses); err != nil {
+		log.Fatalf("classgroups.json is broken: %v", err)
+	}
+}
+
+// classGroups merges ClassGroups over the default groups
+func classGroups() map[string][]string {
+	groups := make(map[string][]string, len(defaultClasses.Groups))
+	for name, members := range defaultClasses.Groups {
+		groups[name] = members
+	}
+	for name, members := range configuration.ClassGroups {
+		groups[strings.ToLower(name)] = members
+	}
+	return groups
+}
+
+// classAliases merges ClassAliases over the default aliases
+func classAliases() map[string]string {
+	aliases := make(map[string]string, len(defaultClasses.Aliases))
+	for alias, class := range defaultClasses.Aliases {
+		aliases[alias] = class
+	}
+	for alias, class := range configuration.ClassAliases {
+		aliases[strings.ToLower(alias)] = strings.ToLower(class)
+	}
+	return aliases
+}
+
+// getClassesByType expands a class, alias or group into class names, anything
+// unknown is returned as is
+func getClassesByType(t string) []string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	classes := expandClassGroup(t, classGroups(), classAliases(), make(map[string]bool))
+	if len(classes) == 0 {
+		return []string{t} // default to just the class provided
+	}
+	return classes
+}
+
+// expandClassGroup resolves nested groups, visited stops a group that contains itself looping forever
+func expandClassGroup(name string, groups map[string][]string, aliases map[string]string, visited map[string]bool) []string {
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	if containsString(eqClasses, name) {
+		return []string{name}
+	}
+	members, ok := groups[name]
+	if !ok || visited[name] {
+		return nil
+	}
+	visited[name] = true
+	var classes []string
+	for _, member := range members {
+		for _, class := range expandClassGroup(strings.ToLower(member), groups, aliases, visited) {
+			if !containsString(classes, class) {
+				classes = append(classes, class)
+			}
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// ListClassGroups shows every class group and alias
+func ListClassGroups(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("ListClassGroups-classgroups.go")
+	defer l.End()
+	groups := classGroups()
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	response = "Groups:\n"
+	for _, name := range names {
+		response = fmt.Sprintf("%s%s:\t%s\n", response, name, titleList(getClassesByType(name)))
+	}
+	aliases := classAliases()
+	byClass := make(map[string][]string)
+	for alias, class := range aliases {
+		byClass[class] = append(byClass[class], alias)
+	}
+	response += "\nAliases:\n"
+	for _, class := range eqClasses {
+		if len(byClass[class]) == 0 {
+			continue
+		}
+		sort.Strings(byClass[class])
+		response = fmt.Sprintf("%s%s:\t%s\n", response, strings.Title(class), strings.Join(byClass[class], ", "))
+	}
+	return response
+}
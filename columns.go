@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// headerSearchRows is how far down a sheet we look for its header row
+const headerSearchRows = 10
+
+// Logical columns, configure extra headers for them in ColumnAliases as "<sheet>.<column>"
+const (
+	colPlayer     = "player"
+	colLevel      = "level"
+	colClass      = "class"
+	colRank       = "rank"
+	colJoinDate   = "joindate"
+	colMain       = "main"
+	colLastRaid   = "lastraid"
+	colAttendance = "attendance"
+	colDKP        = "dkp"
+	colDate       = "date"
+	colDesc       = "description"
+)
+
+// Sheets with header mapped columns
+const (
+	sheetRoster  = "roster"
+	sheetDKP     = "dkp"
+	sheetSummary = "summary"
+)
+
+// columnDef is a logical column and the headers that identify it
+type columnDef struct {
+	key      string
+	headers  []string
+	required bool
+}
+
+// sheetColumnDefs are the columns we read from each sheet
+var sheetColumnDefs = map[string][]columnDef{
+	sheetRoster: {
+		{colPlayer, []string{"Name", "Player", "Character"}, true},
+		{colLevel, []string{"Level", "Lvl"}, true},
+		{colClass, []string{"Class"}, true},
+		{colRank, []string{"Rank"}, true},
+		{colJoinDate, []string{"Join Date", "Joined", "Date Joined"}, false},
+		{colMain, []string{"Main", "Main Character", "Alt Of"}, false},
+	},
+	sheetDKP: {
+		{colClass, []string{"Class"}, false},
+		{colRank, []string{"Rank"}, false},
+		{colPlayer, []string{"Name", "Player", "Character"}, true},
+		{colLevel, []string{"Level", "Lvl"}, false},
+		{colLastRaid, []string{"Last Raid", "Last Raided", "Last Attended"}, true},
+		{colAttendance, []string{"Attendance", "Attendance %", "RA", "Raid Attendance"}, true},
+		{colDKP, []string{"DKP", "Current DKP", "Total DKP", "Current"}, true},
+	},
+	sheetSummary: {
+		{colDate, []string{"Date", "Raid Date"}, true},
+		{colPlayer, []string{"Name", "Player", "Character"}, true},
+		{colDesc, []string{"Description", "Desc", "Reason", "Item"}, true},
+		{colDKP, []string{"DKP", "Points", "Amount"}, true},
+	},
+}
+
+// sheetLayout is where each logical column was found on a sheet
+type sheetLayout struct {
+	sheet     string
+	headerRow int
+	cols      map[string]int
+}
+
+// layoutError is a sheet whose headers don't match what we expect
+type layoutError struct {
+	sheet   string
+	missing []string
+}
+
+func (e *layoutError) Error() string {
+	return fmt.Sprintf("%s sheet layout mismatch, can't find the %s column(s). Check the sheet headers or add them to ColumnAliases", e.sheet, strings.Join(e.missing, ", "))
+}
+
+// columnHeaders returns the configured aliases followed by the default headers for a column
+func columnHeaders(sheet string, def columnDef) []string {
+	aliases := configuration.ColumnAliases[sheet+"."+def.key]
+	headers := make([]string, len(aliases), len(aliases)+len(def.headers))
+	copy(headers, aliases) // never append to the config's slice, it's shared between goroutines
+	return append(headers, def.headers...)
+}
+
+// mapColumns finds the header row of a sheet and the index of each column on it
+func mapColumns(sheet string, rows [][]interface{}) (sheetLayout, error) {
+	l := LogInit("mapColumns-columns.go")
+	defer l.End()
+	defs := sheetColumnDefs[sheet]
+	var best *layoutError
+	for r, row := range rows {
+		if r >= headerSearchRows {
+			break
+		}
+		layout := sheetLayout{sheet: sheet, headerRow: r, cols: make(map[string]int)}
+		var missing []string
+		for _, def := range defs {
+			col := findHeader(row, columnHeaders(sheet, def))
+			if col >= 0 {
+				layout.cols[def.key] = col
+			} else if def.required {
+				missing = append(missing, def.headers[0])
+			}
+		}
+		if len(missing) == 0 {
+			l.TraceF("%s sheet header is row %d: %v", sheet, r, layout.cols)
+			return layout, nil
+		}
+		if best == nil || len(missing) < len(best.missing) {
+			best = &layoutError{sheet: sheet, missing: missing}
+		}
+	}
+	if best == nil {
+		best = &layoutError{sheet: sheet, missing: []string{"every"}}
+	}
+	l.ErrorF("%s", best.Error())
+	return sheetLayout{}, best
+}
+
+// findHeader returns the first column whose header matches one of headers
+func findHeader(row []interface{}, headers []string) int {
+	for i, cell := range row {
+		value := strings.TrimSpace(fmt.Sprintf("%v", cell))
+		for _, header := range headers {
+			if strings.EqualFold(value, header) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// has returns true if the column was found
+func (sl sheetLayout) has(key string) bool {
+	_, ok := sl.cols[key]
+	return ok
+}
+
+// cell returns the value of a column in row, or "" if the row is too short or the column is missing
+func (sl sheetLayout) cell(row []interface{}, key string) string {
+	col, ok := sl.cols[key]
+	if !ok || col >= len(row) {
+		return ""
+	}
+	return fmt.Sprintf("%v", row[col])
+}
+
+// dataRows returns the rows under the header
+func (sl sheetLayout) dataRows(rows [][]interface{}) [][]interface{} {
+	if sl.headerRow+1 >= len(rows) {
+		return nil
+	}
+	return rows[sl.headerRow+1:]
+}
+
+// validateSheetLayouts checks every header mapped sheet, returning the problems found
+func validateSheetLayouts() []error {
+	l := LogInit("validateSheetLayouts-columns.go")
+	defer l.End()
+	var errs []error
+	sources := []struct {
+		sheet string
+		read  func() ([][]interface{}, error)
+	}{
+		{sheetRoster, dataSource.Roster},
+		{sheetDKP, dataSource.DKP},
+		{sheetSummary, dataSource.Summary},
+	}
+	for _, source := range sources {
+		rows, err := source.read()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to read the %s sheet: %v", source.sheet, err))
+			continue
+		}
+		if _, err := mapColumns(source.sheet, rows); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
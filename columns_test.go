@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheet     string
+		csv       string
+		aliases   map[string][]string
+		headerRow int
+		cols      map[string]int
+		wantErr   bool
+	}{
+		{
+			name:  "summary header on the first row",
+			sheet: sheetSummary,
+			csv:   "Date,Name,Description,DKP\n01/02/2021,Mortimus,Raid,5\n",
+			cols:  map[string]int{colDate: 0, colPlayer: 1, colDesc: 2, colDKP: 3},
+		},
+		{
+			name:      "header below a title, columns moved and matched case insensitively",
+			sheet:     sheetSummary,
+			csv:       "Guild DKP\n,,,\n points , raid date ,CHARACTER,Reason\n5,01/02/2021,Mortimus,Raid\n",
+			headerRow: 2,
+			cols:      map[string]int{colDate: 1, colPlayer: 2, colDesc: 3, colDKP: 0},
+		},
+		{
+			name:  "optional columns are left out when missing",
+			sheet: sheetRoster,
+			csv:   "Name,Level,Class,Rank\nMortimus,60,Wizard,Member\n",
+			cols:  map[string]int{colPlayer: 0, colLevel: 1, colClass: 2, colRank: 3},
+		},
+		{
+			name:    "configured alias",
+			sheet:   sheetDKP,
+			csv:     "Toon,Last Raid,RA,Current\nMortimus,01/02/2021,90%,100\n",
+			aliases: map[string][]string{"dkp.player": {"Toon"}},
+			cols:    map[string]int{colPlayer: 0, colLastRaid: 1, colAttendance: 2, colDKP: 3},
+		},
+		{
+			name:    "missing required column",
+			sheet:   sheetSummary,
+			csv:     "Date,Name,DKP\n01/02/2021,Mortimus,5\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty sheet",
+			sheet:   sheetSummary,
+			csv:     "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCSVSheets(t, map[string]string{tt.sheet: tt.csv})
+			configuration.ColumnAliases = tt.aliases
+			defer func() { configuration.ColumnAliases = nil }()
+			var rows [][]interface{}
+			var err error
+			switch tt.sheet {
+			case sheetRoster:
+				rows, err = dataSource.Roster()
+			case sheetDKP:
+				rows, err = dataSource.DKP()
+			default:
+				rows, err = dataSource.Summary()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			layout, err := mapColumns(tt.sheet, rows)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected a layout error, got %v", layout.cols)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if layout.headerRow != tt.headerRow {
+				t.Errorf("header row = %d, want %d", layout.headerRow, tt.headerRow)
+			}
+			if !reflect.DeepEqual(layout.cols, tt.cols) {
+				t.Errorf("cols = %v, want %v", layout.cols, tt.cols)
+			}
+		})
+	}
+}
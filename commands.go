@@ -18,7 +18,7 @@ import (
 )
 
 // BotAction is the function called when a BotCommand is triggered
-type BotAction func(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string)
+type BotAction func(s *discordgo.Session, m *discordgo.MessageCreate, message []string) BotResponse
 
 // BotCommand contains everything for a bot response to a user
 type BotCommand struct {
@@ -29,6 +29,7 @@ type BotCommand struct {
 	priviledged bool      // requires a priviledged role to activate
 	hidden      bool      // do not list in !help
 	minParams   int       // Min # of parameters required
+	audited     bool      // record every use in the audit log
 }
 
 var botCommands []BotCommand
@@ -56,7 +57,7 @@ func initBotCommands() {
 	summaryCommand := BotCommand{
 		command:     configuration.CommRaidSummaryCommand,
 		help:        configuration.CommRaidSummaryHelp,
-		action:      LookupDKPSummary,
+		action:      textAction(LookupDKPSummary),
 		dmOnly:      configuration.CommRaidSummaryDMOnly,
 		priviledged: configuration.CommRaidSummaryPriv,
 		hidden:      configuration.CommRaidSummaryHidden,
@@ -76,7 +77,7 @@ func initBotCommands() {
 	dbrCommand := BotCommand{
 		command:     configuration.CommDBRCommand,
 		help:        configuration.CommDBRHelp,
-		action:      DBR,
+		action:      textAction(DBR),
 		dmOnly:      configuration.CommDBRDMOnly,
 		priviledged: configuration.CommDBRPriv,
 		hidden:      configuration.CommDBRHidden,
@@ -86,7 +87,7 @@ func initBotCommands() {
 	kronoCommand := BotCommand{
 		command:     configuration.CommKronoCommand,
 		help:        configuration.CommKronoHelp,
-		action:      LookupKrono,
+		action:      textAction(LookupKrono),
 		dmOnly:      configuration.CommKronoDMOnly,
 		priviledged: configuration.CommKronoPriv,
 		hidden:      configuration.CommKronoHidden,
@@ -96,7 +97,7 @@ func initBotCommands() {
 	spellCommand := BotCommand{
 		command:     configuration.CommSpellCommand,
 		help:        configuration.CommSpellHelp,
-		action:      GetPlayerSpell,
+		action:      textAction(GetPlayerSpell),
 		dmOnly:      configuration.CommSpellDMOnly,
 		priviledged: configuration.CommSpellPriv,
 		hidden:      configuration.CommSpellHidden,
@@ -106,17 +107,18 @@ func initBotCommands() {
 	givespellCommand := BotCommand{
 		command:     configuration.CommGiveSpellCommand,
 		help:        configuration.CommGiveSpellHelp,
-		action:      SetPlayerSpell,
+		action:      textAction(SetPlayerSpell),
 		dmOnly:      configuration.CommGiveSpellDMOnly,
 		priviledged: configuration.CommGiveSpellPriv,
 		hidden:      configuration.CommGiveSpellHidden,
+		audited:     true,
 	}
 	botCommands = append(botCommands, givespellCommand)
 	//------------------------------------------------
 	readRules := BotCommand{
 		command:     configuration.CommRulesCommand,
 		help:        configuration.CommRulesHelp,
-		action:      ReadRules,
+		action:      textAction(ReadRules),
 		dmOnly:      configuration.CommRulesDMOnly,
 		priviledged: configuration.CommRulesPriv,
 		hidden:      configuration.CommRulesHidden,
@@ -126,7 +128,7 @@ func initBotCommands() {
 	testCommand := BotCommand{
 		command:     "!test",
 		help:        "Used for administrative purposes only",
-		action:      TestCommand,
+		action:      textAction(TestCommand),
 		dmOnly:      true,
 		priviledged: true,
 		hidden:      true,
@@ -136,7 +138,7 @@ func initBotCommands() {
 	sendCommand := BotCommand{
 		command:     "!send",
 		help:        "Used for administrative purposes only",
-		action:      SendMessage,
+		action:      textAction(SendMessage),
 		dmOnly:      true,
 		priviledged: true,
 		hidden:      true,
@@ -156,7 +158,7 @@ func initBotCommands() {
 	raidCommand := BotCommand{
 		command:     configuration.CommRaidCalCommand,
 		help:        configuration.CommRaidCalHelp,
-		action:      GetRaids,
+		action:      textAction(GetRaids),
 		dmOnly:      configuration.CommRaidCalDMOnly,
 		priviledged: configuration.CommRaidCalPriv,
 		hidden:      configuration.CommRaidCalHidden,
@@ -165,17 +167,236 @@ func initBotCommands() {
 	dkpTenCommand := BotCommand{
 		command:     configuration.CommDKPTenCommand,
 		help:        configuration.CommDKPTenHelp,
-		action:      LookupDKPByTopTen,
+		action:      LookupLeaderboard,
 		dmOnly:      configuration.CommDKPTenDMOnly,
 		priviledged: configuration.CommDKPTenPriv,
 		hidden:      configuration.CommDKPTenHidden,
 	}
 	botCommands = append(botCommands, dkpTenCommand)
 	//------------------------------------------------
+	resistsCommand := BotCommand{
+		command:     configuration.CommResistsCommand,
+		help:        configuration.CommResistsHelp,
+		action:      textAction(LookupResists),
+		dmOnly:      configuration.CommResistsDMOnly,
+		priviledged: configuration.CommResistsPriv,
+		hidden:      configuration.CommResistsHidden,
+		minParams:   1,
+	}
+	botCommands = append(botCommands, resistsCommand)
+	//------------------------------------------------
+	itemCommand := BotCommand{
+		command:     configuration.CommItemCommand,
+		help:        configuration.CommItemHelp,
+		action:      textAction(LookupItem),
+		dmOnly:      configuration.CommItemDMOnly,
+		priviledged: configuration.CommItemPriv,
+		hidden:      configuration.CommItemHidden,
+		minParams:   1,
+	}
+	botCommands = append(botCommands, itemCommand)
+	//------------------------------------------------
+	spellInfoCommand := BotCommand{
+		command:     configuration.CommSpellInfoCommand,
+		help:        configuration.CommSpellInfoHelp,
+		action:      textAction(LookupSpellInfo),
+		dmOnly:      configuration.CommSpellInfoDMOnly,
+		priviledged: configuration.CommSpellInfoPriv,
+		hidden:      configuration.CommSpellInfoHidden,
+		minParams:   1,
+	}
+	botCommands = append(botCommands, spellInfoCommand)
+	//------------------------------------------------
+	dropsCommand := BotCommand{
+		command:     configuration.CommDropsCommand,
+		help:        configuration.CommDropsHelp,
+		action:      textAction(LookupDrops),
+		dmOnly:      configuration.CommDropsDMOnly,
+		priviledged: configuration.CommDropsPriv,
+		hidden:      configuration.CommDropsHidden,
+		minParams:   1,
+	}
+	botCommands = append(botCommands, dropsCommand)
+	//------------------------------------------------
+	lootCommand := BotCommand{
+		command:     configuration.CommLootCommand,
+		help:        configuration.CommLootHelp,
+		action:      textAction(LookupLoot),
+		dmOnly:      configuration.CommLootDMOnly,
+		priviledged: configuration.CommLootPriv,
+		hidden:      configuration.CommLootHidden,
+		minParams:   1,
+	}
+	botCommands = append(botCommands, lootCommand)
+	//------------------------------------------------
+	spawnCommand := BotCommand{
+		command:     configuration.CommSpawnCommand,
+		help:        configuration.CommSpawnHelp,
+		action:      textAction(LookupSpawn),
+		dmOnly:      configuration.CommSpawnDMOnly,
+		priviledged: configuration.CommSpawnPriv,
+		hidden:      configuration.CommSpawnHidden,
+		minParams:   1,
+	}
+	botCommands = append(botCommands, spawnCommand)
+	//------------------------------------------------
+	killedCommand := BotCommand{
+		command:     configuration.CommKilledCommand,
+		help:        configuration.CommKilledHelp,
+		action:      textAction(ReportKill),
+		dmOnly:      configuration.CommKilledDMOnly,
+		priviledged: configuration.CommKilledPriv,
+		hidden:      configuration.CommKilledHidden,
+		minParams:   1,
+	}
+	botCommands = append(botCommands, killedCommand)
+	//------------------------------------------------
+	timersCommand := BotCommand{
+		command:     configuration.CommTimersCommand,
+		help:        configuration.CommTimersHelp,
+		action:      textAction(ListTimers),
+		dmOnly:      configuration.CommTimersDMOnly,
+		priviledged: configuration.CommTimersPriv,
+		hidden:      configuration.CommTimersHidden,
+	}
+	botCommands = append(botCommands, timersCommand)
+	//------------------------------------------------
+	iamCommand := BotCommand{
+		command:     configuration.CommIAmCommand,
+		help:        configuration.CommIAmHelp,
+		action:      textAction(LinkCharacter),
+		dmOnly:      configuration.CommIAmDMOnly,
+		priviledged: configuration.CommIAmPriv,
+		hidden:      configuration.CommIAmHidden,
+		minParams:   1,
+	}
+	botCommands = append(botCommands, iamCommand)
+	//------------------------------------------------
+	linkApproveCommand := BotCommand{
+		command:     configuration.CommLinkApproveCommand,
+		help:        configuration.CommLinkApproveHelp,
+		action:      textAction(ApproveLink),
+		dmOnly:      configuration.CommLinkApproveDMOnly,
+		priviledged: configuration.CommLinkApprovePriv,
+		hidden:      configuration.CommLinkApproveHidden,
+		audited:     true,
+	}
+	botCommands = append(botCommands, linkApproveCommand)
+	//------------------------------------------------
+	altsCommand := BotCommand{
+		command:     configuration.CommAltsCommand,
+		help:        configuration.CommAltsHelp,
+		action:      textAction(ListAlts),
+		dmOnly:      configuration.CommAltsDMOnly,
+		priviledged: configuration.CommAltsPriv,
+		hidden:      configuration.CommAltsHidden,
+	}
+	botCommands = append(botCommands, altsCommand)
+	//------------------------------------------------
+	refreshCommand := BotCommand{
+		command:     configuration.CommRefreshCommand,
+		help:        configuration.CommRefreshHelp,
+		action:      textAction(RefreshRoster),
+		dmOnly:      configuration.CommRefreshDMOnly,
+		priviledged: true, // every refresh reads the sheets, only officers can force one
+		hidden:      configuration.CommRefreshHidden,
+	}
+	botCommands = append(botCommands, refreshCommand)
+	//------------------------------------------------
+	ledger := BotCommand{
+		command:     configuration.CommLedgerCommand,
+		help:        configuration.CommLedgerHelp,
+		action:      textAction(Ledger),
+		dmOnly:      configuration.CommLedgerDMOnly,
+		priviledged: configuration.CommLedgerPriv,
+		hidden:      configuration.CommLedgerHidden,
+	}
+	botCommands = append(botCommands, ledger)
+	//------------------------------------------------
+	auction := BotCommand{
+		command:     configuration.CommAuctionCommand,
+		help:        configuration.CommAuctionHelp,
+		action:      textAction(StartAuction),
+		dmOnly:      configuration.CommAuctionDMOnly,
+		priviledged: true, // auctions are run by officers
+		hidden:      configuration.CommAuctionHidden,
+	}
+	botCommands = append(botCommands, auction)
+	//------------------------------------------------
+	bid := BotCommand{
+		command:     configuration.CommBidCommand,
+		help:        configuration.CommBidHelp,
+		action:      textAction(PlaceBid),
+		dmOnly:      configuration.CommBidDMOnly,
+		priviledged: configuration.CommBidPriv,
+		hidden:      configuration.CommBidHidden,
+	}
+	botCommands = append(botCommands, bid)
+	//------------------------------------------------
+	award := BotCommand{
+		command:     configuration.CommAwardCommand,
+		help:        configuration.CommAwardHelp,
+		action:      textAction(AwardItem),
+		dmOnly:      configuration.CommAwardDMOnly,
+		priviledged: true, // awards charge DKP, only officers can make them
+		hidden:      configuration.CommAwardHidden,
+	}
+	botCommands = append(botCommands, award)
+	//------------------------------------------------
+	history := BotCommand{
+		command:     configuration.CommHistoryCommand,
+		help:        configuration.CommHistoryHelp,
+		action:      LookupHistory,
+		dmOnly:      configuration.CommHistoryDMOnly,
+		priviledged: configuration.CommHistoryPriv,
+		hidden:      configuration.CommHistoryHidden,
+	}
+	botCommands = append(botCommands, history)
+	//------------------------------------------------
+	attendance := BotCommand{
+		command:     configuration.CommAttendanceCommand,
+		help:        configuration.CommAttendanceHelp,
+		action:      textAction(LookupAttendance),
+		dmOnly:      configuration.CommAttendanceDMOnly,
+		priviledged: configuration.CommAttendancePriv,
+		hidden:      configuration.CommAttendanceHidden,
+	}
+	botCommands = append(botCommands, attendance)
+	//------------------------------------------------
+	decay := BotCommand{
+		command:     configuration.CommDecayCommand,
+		help:        configuration.CommDecayHelp,
+		action:      textAction(Decay),
+		dmOnly:      configuration.CommDecayDMOnly,
+		priviledged: configuration.CommDecayPriv,
+		hidden:      configuration.CommDecayHidden,
+	}
+	botCommands = append(botCommands, decay)
+	//------------------------------------------------
+	groups := BotCommand{
+		command:     configuration.CommGroupsCommand,
+		help:        configuration.CommGroupsHelp,
+		action:      textAction(ListClassGroups),
+		dmOnly:      configuration.CommGroupsDMOnly,
+		priviledged: configuration.CommGroupsPriv,
+		hidden:      configuration.CommGroupsHidden,
+	}
+	botCommands = append(botCommands, groups)
+	//------------------------------------------------
+	whoCan := BotCommand{
+		command:     configuration.CommWhoCanCommand,
+		help:        configuration.CommWhoCanHelp,
+		action:      LookupWhoCan,
+		dmOnly:      configuration.CommWhoCanDMOnly,
+		priviledged: configuration.CommWhoCanPriv,
+		hidden:      configuration.CommWhoCanHidden,
+	}
+	botCommands = append(botCommands, whoCan)
+	//------------------------------------------------
 	// changeConfig := BotCommand{
 	// 	command:     "!config",
 	// 	help:        "Let's you modify configuration without modifying code",
-	// 	action:      ChangeConfig,
+	// 	action:      textAction(ChangeConfig),
 	// 	dmOnly:      true,
 	// 	priviledged: true,
 	// 	hidden:      true,
@@ -191,7 +412,7 @@ func initBotCommands() {
 	// botCommands = append(botCommands, rollCommand)
 }
 
-func runCommand(s *discordgo.Session, m *discordgo.MessageCreate, message []string) string { // prolly replace user with session to check for dm/rank
+func runCommand(s *discordgo.Session, m *discordgo.MessageCreate, message []string) BotResponse { // prolly replace user with session to check for dm/rank
 	l := LogInit("runCommand-commands.go")
 	defer l.End()
 	if len(message) > 0 && len(message[0]) > 0 && message[0][0] == '!' { // Command attempted
@@ -202,32 +423,38 @@ func runCommand(s *discordgo.Session, m *discordgo.MessageCreate, message []stri
 				l.InfoF("Command: %s matches %s", strings.ToLower(message[0]), command.command)
 				if command.dmOnly && !ComesFromDM(s, m) {
 					l.InfoF("Command is dm only and coming outside of DM's: %s", message)
-					return ""
+					return BotResponse{}
 				}
 				if command.priviledged && !isPriviledged(s, m.Author.ID) {
 					l.WarnF("Command is priviledged only and coming from a nont-privledged user: %s -- %v", message, m.Author)
-					return configuration.NoPrivResponse
+					return textResponse(configuration.NoPrivResponse)
+				}
+				if command.priviledged || command.audited {
+					audit(m, m.Content)
 				}
 				response := command.action(s, m, message)
-				l.TraceF("Message complete, responding with: %s", response)
+				l.TraceF("Message complete, responding with: %s", response.text)
 				return response
 			}
 		}
 	}
-	return ""
+	return BotResponse{}
 }
 
 // Help lists all commands registered
-func Help(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+func Help(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response BotResponse) {
 	l := LogInit("Help-commands.go")
 	defer l.End()
+	var fields []*discordgo.MessageEmbedField
 	for _, command := range botCommands {
 		if !command.hidden {
-			response += fmt.Sprintf("%s: %s\n", command.command, command.help)
+			response.text += fmt.Sprintf("%s: %s\n", command.command, command.help)
+			fields = append(fields, &discordgo.MessageEmbedField{Name: command.command, Value: command.help})
 		} else {
 			l.InfoF("Skipping command %s due to being hidden", command.command)
 		}
 	}
+	response.embeds = fieldEmbeds("Commands", defaultEmbedColor, fields, nil)
 	return response
 }
 
@@ -323,6 +550,7 @@ type Player struct {
 	lastRaid   string
 	attendance string
 	dkp        int
+	main       string // main character if this is an alt
 }
 
 // ByDKP is for sorting players dkp
@@ -333,123 +561,136 @@ func (a byDKP) Less(i, j int) bool { return a[i].dkp < a[j].dkp }
 func (a byDKP) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
 // LookupDKP find the message[1] user's DKP on the known google spreadsheet
-func LookupDKP(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+func LookupDKP(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response BotResponse) {
 	l := LogInit("LookupDKP-commands.go")
 	defer l.End()
+	var arg string
 	if len(message) > 1 {
-		result := lookupPlayer(message[1])
-		if result.name == "" {
+		arg = message[1]
+	}
+	name, err := resolveCharacter(m, arg)
+	if err != nil {
+		l.InfoF("Unable to resolve a character from %s: %s", message, err.Error())
+		return textResponse(err.Error())
+	}
+	result := lookupPlayer(name)
+	if result.name == "" {
+		if arg != "" && !mentionRegex.MatchString(arg) {
 			return LookupDKPByClass(s, m, message)
 		}
-		response = fmt.Sprintf("%s(%s):\t%d", result.name, result.rank, result.dkp)
-		return response
+		return textResponse(fmt.Sprintf("%s is not on the DKP sheet", name))
+	}
+	group, ok := findGroup(groupByMain(lookupAllPlayer()), result.name)
+	if ok && len(group.alts) > 0 {
+		response.text = group.format() + rosterAge()
 	} else {
-		l.ErrorF("DKP command ran without a player: %s", message)
+		group = PlayerGroup{main: result}
+		response.text = fmt.Sprintf("%s(%s):\t%d\n", result.name, result.rank, result.dkp) + rosterAge()
 	}
-	return ""
+	response.embeds = []*discordgo.MessageEmbed{group.embed()}
+	return response
 }
 
 // LookupDKPByClass find the message[1] class DKP on the known google spreadsheet
-func LookupDKPByClass(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+func LookupDKPByClass(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response BotResponse) {
 	l := LogInit("LookupDKPByClass-commands.go")
 	defer l.End()
 	if len(message) > 1 {
 		l.TraceF("Looking up dkp for classe(s): %s\n", message[1])
-		var result []Player
+		class := message[1]
 		if len(message) > 2 {
-			result = lookupPlayersByClass(message[1] + " " + message[2]) // stupid shadow knights
-		} else {
-			result = lookupPlayersByClass(message[1])
+			class = message[1] + " " + message[2] // stupid shadow knights
+		}
+		classes := getClassesByType(strings.ToLower(class))
+		var groups []PlayerGroup
+		var mains []Player
+		for _, group := range groupByMain(lookupAllPlayer()) {
+			if containsString(classes, strings.TrimSpace(strings.ToLower(group.main.class))) {
+				groups = append(groups, group)
+				mains = append(mains, group.main)
+			}
+		}
+		if len(groups) == 0 {
+			return BotResponse{}
 		}
-		sort.Sort(sort.Reverse(byDKP(result)))
-		for _, res := range result {
-			response = fmt.Sprintf("%s%s(%s):\t%d\n", response, res.name, res.rank, res.dkp)
+		sort.SliceStable(groups, func(i, j int) bool { return groups[i].dkp() > groups[j].dkp() })
+		var fields []*discordgo.MessageEmbedField
+		for _, group := range groups {
+			response.text += group.format()
+			field := group.field()
+			field.Inline = true
+			fields = append(fields, field)
 		}
+		response.text += rosterAge()
+		response.embeds = fieldEmbeds(fmt.Sprintf("%s DKP", strings.Title(strings.ToLower(class))), classColor(mains...), fields, dataFooter())
 		return response
 	} else {
 		l.ErrorF("DKP command ran without a player: %s", message)
 	}
-	return ""
+	return BotResponse{}
 }
 
-// LookupDKPByTopTen find the top ten DKP holders on the known google spreadsheet
-func LookupDKPByTopTen(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
-	l := LogInit("LookupDKPByTopTen-commands.go")
-	defer l.End()
-	l.TraceF("Looking up dkp for top ten\n")
-	result := lookupAllPlayer()
-	sort.Sort(sort.Reverse(byDKP(result)))
-	var topTen []Player
-	if len(result) > 10 {
-		topTen = result[0:10] // Why this goes to 10 is beyond me
-	} else {
-		topTen = result
-	}
-	for _, res := range topTen {
-		response = fmt.Sprintf("%s%s(%s):\t%d\n", response, res.name, res.rank, res.dkp)
-	}
-	return response
-}
-
-func lookupAllPlayer() []Player {
-	l := LogInit("lookupAllPlayer-commands.go")
+// fetchAllPlayers reads the roster and DKP sheets, use lookupAllPlayer for the cached copy
+func fetchAllPlayers() ([]Player, error) {
+	l := LogInit("fetchAllPlayers-commands.go")
 	defer l.End()
 	var players []Player
 	l.TraceF("Finding all players\n")
 
-	spreadsheetID := configuration.DKPSheetURL
-	readRange := configuration.DKPSRosterSheetName
-	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
+	rows, err := dataSource.Roster()
 	if err != nil {
 		l.ErrorF("Unable to retrieve data from sheet: %v", err)
-		return []Player{}
+		return []Player{}, err
 		// log.Fatalf("Unable to retrieve data from sheet: %v", err)
 	}
 
-	if len(resp.Values) == 0 {
-		l.ErrorF("No player lookup response: %v", resp)
+	roster, err := mapColumns(sheetRoster, rows)
+	if err != nil {
+		return []Player{}, err
+	}
+	if len(rows) == 0 {
+		l.ErrorF("No player lookup response: %v", rows)
 		// log.Println("No data found.")
 	} else {
-		// var lastClass string
-		for _, row := range resp.Values {
-			// pulledClass := fmt.Sprintf("%s", row[configuration.DKPSRosterSheetClassCol])
-			// pulledClass = strings.ToLower(pulledClass)
-			if row[configuration.DKPSRosterSheetPlayerCol] != "" { // No blank rows
+		for _, row := range roster.dataRows(rows) {
+			if roster.cell(row, colPlayer) != "" { // No blank rows
 				player := Player{}
-				player.class = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetClassCol])
-				player.rank = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetRankCol])
-				player.name = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetPlayerCol])
-				player.level = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetLevelCol])
+				player.class = roster.cell(row, colClass)
+				player.rank = roster.cell(row, colRank)
+				player.name = roster.cell(row, colPlayer)
+				player.level = roster.cell(row, colLevel)
+				player.main = normalizeCharacter(roster.cell(row, colMain))
 				players = append(players, player)
 			}
 		}
 	}
-	readRange = configuration.DKPSheetName
-	resp2, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
+	rows2, err := dataSource.DKP()
 	if err != nil {
 		l.ErrorF("Unable to retrieve data from sheet 2nd pass: %v", err)
-		return []Player{}
+		return []Player{}, err
 		// log.Fatalf("Unable to retrieve data from sheet: %v", err)
 	}
 
-	if len(resp2.Values) == 0 {
-		l.ErrorF("No player lookup response: %v", resp)
+	dkpSheet, err := mapColumns(sheetDKP, rows2)
+	if err != nil {
+		return []Player{}, err
+	}
+	if len(rows2) == 0 {
+		l.ErrorF("No player lookup response: %v", rows)
 		// log.Println("No data found.")
 	} else {
-		var lastClass string
-		for _, row := range resp2.Values {
-			if row[configuration.DKPSheetClassCol] != "" {
-				lastClass = fmt.Sprintf("%s", row[configuration.DKPSheetClassCol])
-				lastClass = strings.ToLower(lastClass)
+		for _, row := range dkpSheet.dataRows(rows2) {
+			name := dkpSheet.cell(row, colPlayer)
+			if name == "" {
+				continue
 			}
-			name := fmt.Sprintf("%v", row[configuration.DKPSheetNameCol])
 			index := findPlayerIndexInArray(name, &players)
 			if index < 0 {
 				continue // We don't know who the fuck this is
 			}
-			players[index].lastRaid = fmt.Sprintf("%v", row[configuration.DKPSheetLastRaidCol])
-			players[index].attendance = fmt.Sprintf("%v", row[configuration.DKPSheetAttendanceCol])
-			dkp, err := strconv.Atoi(strings.ReplaceAll(fmt.Sprintf("%v", row[configuration.DKPSheetDKPCol]), ",", ""))
+			players[index].lastRaid = dkpSheet.cell(row, colLastRaid)
+			players[index].attendance = dkpSheet.cell(row, colAttendance)
+			dkp, err := strconv.Atoi(strings.ReplaceAll(dkpSheet.cell(row, colDKP), ",", ""))
 			if err != nil {
 				dkp = 0
 			}
@@ -457,7 +698,7 @@ func lookupAllPlayer() []Player {
 			continue
 		}
 	}
-	return players
+	return players, nil
 }
 
 func lookupPlayersByClass(tarClass string) []Player {
@@ -467,104 +708,15 @@ func lookupPlayersByClass(tarClass string) []Player {
 	classes := getClassesByType(tarClass)
 	var players []Player
 	l.TraceF("Finding players based on classes: %#+v", classes)
-
-	spreadsheetID := configuration.DKPSheetURL
-	readRange := configuration.DKPSRosterSheetName
-	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
-	if err != nil {
-		l.ErrorF("Unable to retrieve data from sheet: %v", err)
-		return []Player{}
-		// log.Fatalf("Unable to retrieve data from sheet: %v", err)
-	}
-
-	if len(resp.Values) == 0 {
-		l.ErrorF("No player lookup response: %v", resp)
-		// log.Println("No data found.")
-	} else {
-		// var lastClass string
-		for _, row := range resp.Values {
-			// if row[0] == "Necromancer" {
-			// 	fmt.Printf("%s: %s\n", row[2], row[6])
-			// }
-			// l.TraceF("Player: %s Target: %s", row[configuration.DKPSRosterSheetPlayerCol], strings.TrimSpace(tar))
-			pulledClass := fmt.Sprintf("%s", row[configuration.DKPSRosterSheetClassCol])
-			pulledClass = strings.ToLower(pulledClass)
-			for _, class := range classes {
-				if strings.TrimSpace(pulledClass) == strings.TrimSpace(class) {
-					player := Player{}
-					player.class = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetClassCol])
-					player.rank = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetRankCol])
-					player.name = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetPlayerCol])
-					player.level = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetLevelCol])
-					players = append(players, player)
-					continue
-				}
-			}
-		}
-		// if pulledClass == "" {
-		// 	l.ErrorF("Class not found on roster - %s", class)
-		// }
-	}
-	readRange = configuration.DKPSheetName
-	resp2, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
-	if err != nil {
-		l.ErrorF("Unable to retrieve data from sheet 2nd pass: %v", err)
-		return []Player{}
-		// log.Fatalf("Unable to retrieve data from sheet: %v", err)
-	}
-
-	if len(resp2.Values) == 0 {
-		l.ErrorF("No player lookup response: %v", resp)
-		// log.Println("No data found.")
-	} else {
-		var lastClass string
-		for _, row := range resp2.Values {
-			// if row[0] == "Necromancer" {
-			// 	fmt.Printf("%s: %s\n", row[2], row[6])
-			// }
-			// name := fmt.Sprintf("%s", row[configuration.DKPSheetNameCol])
-			// l.TraceF("lastClass: %s\n", lastClass)
-
-			if row[configuration.DKPSheetClassCol] != "" {
-				lastClass = fmt.Sprintf("%s", row[configuration.DKPSheetClassCol])
-				lastClass = strings.ToLower(lastClass)
-			}
-			for _, class := range classes {
-				if lastClass == strings.TrimSpace(class) {
-					name := fmt.Sprintf("%v", row[configuration.DKPSheetNameCol])
-					index := findPlayerIndexInArray(name, &players)
-					if index < 0 {
-						continue // We don't know who the fuck this is
-					}
-					// player.class = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetClassCol])
-					// player.rank = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetRankCol])
-					// player.name = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetPlayerCol])
-					// player.level = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetLevelCol])
-
-					players[index].lastRaid = fmt.Sprintf("%v", row[configuration.DKPSheetLastRaidCol])
-					players[index].attendance = fmt.Sprintf("%v", row[configuration.DKPSheetAttendanceCol])
-					// players[index].dkp = fmt.Sprintf("%v", row[configuration.DKPSheetDKPCol])
-					dkp, err := strconv.Atoi(strings.ReplaceAll(fmt.Sprintf("%v", row[configuration.DKPSheetDKPCol]), ",", ""))
-					if err != nil {
-						dkp = 0
-					}
-					players[index].dkp = dkp
-					// player = Player{
-					// 	class:      fmt.Sprintf("%v", row[configuration.DKPSRosterSheetClassCol]),
-					// 	rank:       fmt.Sprintf("%v", row[configuration.DKPSRosterSheetRankCol]),
-					// 	name:       fmt.Sprintf("%v", row[configuration.DKPSRosterSheetPlayerCol]),
-					// 	level:      fmt.Sprintf("%v", row[configuration.DKPSRosterSheetLevelCol]),
-					// 	// lastRaid:   fmt.Sprintf("%v", row[configuration.DKPSheetLastRaidCol]),
-					// 	// attendance: fmt.Sprintf("%v", row[configuration.DKPSheetAttendanceCol]),
-					// 	// dkp:        fmt.Sprintf("%v", row[configuration.DKPSheetDKPCol]),
-					// }
-					continue
-				}
+	for _, player := range lookupAllPlayer() {
+		pulledClass := strings.TrimSpace(strings.ToLower(player.class))
+		for _, class := range classes {
+			if pulledClass == strings.TrimSpace(class) {
+				players = append(players, player)
+				break
 			}
 		}
-		// l.ErrorF("Player not found on DKP listing - %s", tar)
 	}
-
 	return players
 }
 
@@ -580,136 +732,24 @@ func findPlayerIndexInArray(name string, players *[]Player) int {
 	return -1
 }
 
-func getClassesByType(t string) []string {
-	switch t {
-	case "cloth":
-		return []string{"enchanter", "magician", "necromancer", "wizard"}
-	case "leather":
-		return []string{"beastlord", "druid", "monk"}
-	case "chain":
-		return []string{"berserker", "ranger", "rogue", "shaman"}
-	case "plate":
-		return []string{"bard", "cleric", "paladin", "shadow knight", "warrior"}
-	case "priest":
-		return []string{"cleric", "druid", "shaman"}
-	case "melee":
-		return []string{"bard", "beastlord", "berserker", "monk", "paladin", "ranger", "rogue", "shadow knight", "warrior"}
-	case "fist":
-		return []string{"beastlord", "monk"}
-	case "thief":
-		return []string{"bard", "rogue"}
-	case "knight":
-		return []string{"paladin", "shadow knight"}
-	case "deathtouch":
-		return []string{"ranger"}
-	case "tank":
-		return []string{"warrior", "paladin", "shadow knight"}
-	}
-	return []string{t} // default to just the class provided
-	// return nil
-}
-
 func lookupPlayer(tar string) Player {
 	l := LogInit("lookupPlayer-commands.go")
 	defer l.End()
 	tar = strings.ToLower(tar)
 	tar = strings.Title(tar) // Capitilize first letter
-	// player := &Player{}
+	tar = strings.TrimSpace(tar)
+	for _, player := range lookupAllPlayer() {
+		if strings.TrimSpace(player.name) == tar {
+			return player
+		}
+	}
+	l.ErrorF("Player not found on roster - %s", tar)
 	var player Player
 	player.attendance = "No Attendance Found"
 	player.class = "Unknown"
-	// player.dkp = "0"
 	player.lastRaid = "No Raids"
 	player.level = "0"
 	player.rank = "Unknown"
-	spreadsheetID := configuration.DKPSheetURL
-	readRange := configuration.DKPSRosterSheetName
-	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
-	if err != nil {
-		l.ErrorF("Unable to retrieve data from sheet: %v", err)
-		return Player{}
-		// log.Fatalf("Unable to retrieve data from sheet: %v", err)
-	}
-
-	if len(resp.Values) == 0 {
-		l.ErrorF("No player lookup response: %v", resp)
-		// log.Println("No data found.")
-	} else {
-		// var lastClass string
-		for _, row := range resp.Values {
-			// if row[0] == "Necromancer" {
-			// 	fmt.Printf("%s: %s\n", row[2], row[6])
-			// }
-			// l.TraceF("Player: %s Target: %s", row[configuration.DKPSRosterSheetPlayerCol], strings.TrimSpace(tar))
-			name := fmt.Sprintf("%s", row[configuration.DKPSRosterSheetPlayerCol])
-			if strings.TrimSpace(name) == strings.TrimSpace(tar) {
-				player.class = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetClassCol])
-				player.rank = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetRankCol])
-				player.name = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetPlayerCol])
-				player.level = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetLevelCol])
-				// player = Player{
-				// 	class:      fmt.Sprintf("%v", row[configuration.DKPSRosterSheetClassCol]),
-				// 	rank:       fmt.Sprintf("%v", row[configuration.DKPSRosterSheetRankCol]),
-				// 	name:       fmt.Sprintf("%v", row[configuration.DKPSRosterSheetPlayerCol]),
-				// 	level:      fmt.Sprintf("%v", row[configuration.DKPSRosterSheetLevelCol]),
-				// 	// lastRaid:   fmt.Sprintf("%v", row[configuration.DKPSheetLastRaidCol]),
-				// 	// attendance: fmt.Sprintf("%v", row[configuration.DKPSheetAttendanceCol]),
-				// 	// dkp:        fmt.Sprintf("%v", row[configuration.DKPSheetDKPCol]),
-				// }
-				break
-			}
-		}
-		if player.name == "" {
-			l.ErrorF("Player not found on roster - %s", tar)
-		}
-	}
-	readRange = configuration.DKPSheetName
-	resp2, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
-	if err != nil {
-		l.ErrorF("Unable to retrieve data from sheet 2nd pass: %v", err)
-		return Player{}
-		// log.Fatalf("Unable to retrieve data from sheet: %v", err)
-	}
-
-	if len(resp2.Values) == 0 {
-		l.ErrorF("No player lookup response: %v", resp)
-		// log.Println("No data found.")
-	} else {
-		// var lastClass string
-		for _, row := range resp2.Values {
-			// if row[0] == "Necromancer" {
-			// 	fmt.Printf("%s: %s\n", row[2], row[6])
-			// }
-			name := fmt.Sprintf("%s", row[configuration.DKPSheetNameCol])
-			if name == strings.TrimSpace(tar) {
-				// player.class = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetClassCol])
-				// player.rank = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetRankCol])
-				// player.name = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetPlayerCol])
-				// player.level = fmt.Sprintf("%v", row[configuration.DKPSRosterSheetLevelCol])
-
-				player.lastRaid = fmt.Sprintf("%v", row[configuration.DKPSheetLastRaidCol])
-				player.attendance = fmt.Sprintf("%v", row[configuration.DKPSheetAttendanceCol])
-				// player.dkp = fmt.Sprintf("%v", row[configuration.DKPSheetDKPCol])
-				dkp, err := strconv.Atoi(strings.ReplaceAll(fmt.Sprintf("%v", row[configuration.DKPSheetDKPCol]), ",", ""))
-				if err != nil {
-					dkp = 0
-				}
-				player.dkp = dkp
-				// player = Player{
-				// 	class:      fmt.Sprintf("%v", row[configuration.DKPSRosterSheetClassCol]),
-				// 	rank:       fmt.Sprintf("%v", row[configuration.DKPSRosterSheetRankCol]),
-				// 	name:       fmt.Sprintf("%v", row[configuration.DKPSRosterSheetPlayerCol]),
-				// 	level:      fmt.Sprintf("%v", row[configuration.DKPSRosterSheetLevelCol]),
-				// 	// lastRaid:   fmt.Sprintf("%v", row[configuration.DKPSheetLastRaidCol]),
-				// 	// attendance: fmt.Sprintf("%v", row[configuration.DKPSheetAttendanceCol]),
-				// 	// dkp:        fmt.Sprintf("%v", row[configuration.DKPSheetDKPCol]),
-				// }
-				return player
-			}
-		}
-		l.ErrorF("Player not found on DKP listing - %s", tar)
-	}
-
 	return player
 }
 
@@ -722,29 +762,31 @@ func LookupDKPSummary(s *discordgo.Session, m *discordgo.MessageCreate, message
 		player = strings.ToLower(player)
 		player = strings.Title(player) // Capitilize first letter
 		raid := message[2]
-		spreadsheetID := configuration.DKPSheetURL
-		readRange := configuration.DKPSummarySheetName
-		resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
+		rows, err := dataSource.Summary()
 		if err != nil {
 			l.ErrorF("Unable to retrieve data from sheet: %v", err)
 			return ""
 			// log.Fatalf("Unable to retrieve data from sheet: %v", err)
 		}
+		summary, err := mapColumns(sheetSummary, rows)
+		if err != nil {
+			return err.Error()
+		}
 		response = fmt.Sprintf("%s on %s\n", player, raid)
 
-		if len(resp.Values) == 0 {
-			l.ErrorF("No data found. %v", resp)
+		if len(rows) == 0 {
+			l.ErrorF("No data found. %v", rows)
 		} else {
 			found := false
 			var foundrow int
-			for i, row := range resp.Values {
+			for i, row := range summary.dataRows(rows) {
 				// if row[0] == "Necromancer" {
 				// 	fmt.Printf("%s: %s\n", row[2], row[6])
 				// }
-				if row[configuration.DKPSummarySheetPlayerCol] == strings.TrimSpace(player) && strings.Contains(fmt.Sprintf("%s", row[configuration.DKPSummarySheetDateCol]), raid) {
+				if summary.cell(row, colPlayer) == strings.TrimSpace(player) && strings.Contains(summary.cell(row, colDate), raid) {
 					// fmt.Printf("Found row! :: %+v\n", row)
 					found = true
-					response = fmt.Sprintf("%s%s :: %s\n", response, row[configuration.DKPSummarySheetDKPDescCol], row[configuration.DKPSummarySheetDKPCol])
+					response = fmt.Sprintf("%s%s :: %s\n", response, summary.cell(row, colDesc), summary.cell(row, colDKP))
 					foundrow = i
 				} else {
 					// fmt.Printf("Found not row!\n")
@@ -752,7 +794,7 @@ func LookupDKPSummary(s *discordgo.Session, m *discordgo.MessageCreate, message
 					// 	fmt.Printf("%d: %s\n", d, vals)
 					// }
 					if found && foundrow == i-1 {
-						response = fmt.Sprintf("%s\nTotal :: %s\n", response, row[configuration.DKPSummarySheetDKPCol])
+						response = fmt.Sprintf("%s\nTotal :: %s\n", response, summary.cell(row, colDKP))
 						found = false
 						return response
 					}
@@ -772,9 +814,7 @@ func lookupPlayerSpell(player, class, spell string) (bool, string, error) { // T
 	} else {
 		log.Printf("Player: %s Class: %s Spell: %s", player, class, spell)
 	}
-	spreadsheetID := configuration.SpellSheet
-	readRange := class // change based on class TODO: Check against known class names
-	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
+	rows, err := dataSource.Spells(class) // TODO: Check against known class names
 	if err != nil {
 		l.ErrorF("Unable to retrieve data from sheet: %v", err)
 		return false, "Unable to retrieve data at this time", errors.New("Unable to retrieve data from sheet")
@@ -782,11 +822,11 @@ func lookupPlayerSpell(player, class, spell string) (bool, string, error) { // T
 	}
 	player = strings.ToLower(player)
 
-	if len(resp.Values) == 0 {
-		l.ErrorF("No data found in response: %v", resp)
+	if len(rows) == 0 {
+		l.ErrorF("No data found in response: %v", rows)
 	} else {
 		var playerColumn int
-		for i, row := range resp.Values {
+		for i, row := range rows {
 			if len(row) < 1 {
 				// return false, "", errors.New("Spell not found")
 				continue
@@ -827,6 +867,13 @@ func GetPlayerSpell(s *discordgo.Session, m *discordgo.MessageCreate, message []
 	l := LogInit("GetPlayerSpell-commands.go")
 	defer l.End()
 	if len(message) > 2 {
+		if mentionRegex.MatchString(message[1]) {
+			character, err := resolveCharacter(m, message[1])
+			if err != nil {
+				return err.Error()
+			}
+			message[1] = character
+		}
 		player := lookupPlayer(message[1])
 		l.InfoF("Player: %s = %+v", message[1], player)
 		// spellString := message[1:]
@@ -866,7 +913,7 @@ func ColumnNumberToName(num int) (string, error) {
 	return col, nil
 }
 
-func writeToSheet(sheet, cell, value string) {
+func writeToSheet(sheet, cell, value string) error {
 	l := LogInit("writeToSheet-commands.go")
 	defer l.End()
 	var vr sheets.ValueRange
@@ -878,6 +925,19 @@ func writeToSheet(sheet, cell, value string) {
 	if err != nil {
 		l.ErrorF("Unable to retrieve data from sheet. %v", err)
 	}
+	return err
+}
+
+// appendToSheet adds rows after the last row of a sheet in one update
+func appendToSheet(spreadsheet, sheet string, rows [][]interface{}) error {
+	l := LogInit("appendToSheet-commands.go")
+	defer l.End()
+	vr := sheets.ValueRange{Values: rows}
+	_, err := srv.Spreadsheets.Values.Append(spreadsheet, sheet, &vr).ValueInputOption("USER_ENTERED").InsertDataOption("INSERT_ROWS").Do()
+	if err != nil {
+		l.ErrorF("Unable to append to sheet %s. %v", sheet, err)
+	}
+	return err
 }
 
 // SetPlayerSpell updates the spell spreadsheet
@@ -885,6 +945,13 @@ func SetPlayerSpell(s *discordgo.Session, m *discordgo.MessageCreate, message []
 	l := LogInit("SetPlayerSpell-commands.go")
 	defer l.End()
 	if len(message) > 2 {
+		if mentionRegex.MatchString(message[1]) {
+			character, err := resolveCharacter(m, message[1])
+			if err != nil {
+				return err.Error()
+			}
+			message[1] = character
+		}
 		player := lookupPlayer(message[1])
 		l.InfoF("Player: %s = %+v", message[1], player)
 		// spellString := message[1:]
@@ -896,9 +963,12 @@ func SetPlayerSpell(s *discordgo.Session, m *discordgo.MessageCreate, message []
 			return ""
 		}
 		// has := fmt.Sprintf("%t", hasSpell)
-		writeToSheet(configuration.SpellSheet, player.class+"!"+cell, "TRUE")
+		if err := dataSource.SetSpell(player.class, cell, "TRUE"); err != nil {
+			l.ErrorF("Error giving spell: %s\n", err.Error())
+			return "Unable to update the spell sheet at this time"
+		}
 		l.InfoF("%s has been given %s by %v", message[1], spellString, m.Author)
-		response = fmt.Sprintf("%s has been given %s", message[1], spellString) // TODO: Log this for shaming and blaming
+		response = fmt.Sprintf("%s has been given %s", message[1], spellString)
 		return response
 	}
 	return ""
@@ -908,20 +978,18 @@ func SetPlayerSpell(s *discordgo.Session, m *discordgo.MessageCreate, message []
 func ReadRules(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
 	l := LogInit("SetPlayerSpell-commands.go")
 	defer l.End()
-	spreadsheetID := configuration.SpellSheet
-	readRange := configuration.RulesSheetName
-	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
+	rows, err := dataSource.Rules()
 	if err != nil {
 		l.ErrorF("Unable to retrieve data from sheet: %v", err)
 		return "Unable to read rules at this time"
 		// log.Printf("Unable to retrieve data from sheet: %v", err)
 	}
 	// log.Printf("User reading rules\n%+v", user)
-	if len(resp.Values) == 0 {
-		l.ErrorF("No data in sheet response: %v", resp)
+	if len(rows) == 0 {
+		l.ErrorF("No data in sheet response: %v", rows)
 	} else {
 		// log.Printf("Rules found, reading")
-		for _, row := range resp.Values {
+		for _, row := range rows {
 			// if row[0] == "Necromancer" {
 			// 	fmt.Printf("%s: %s\n", row[2], row[6])
 			// }
@@ -976,6 +1044,9 @@ func GetRaids(s *discordgo.Session, m *discordgo.MessageCreate, message []string
 		}
 		l.InfoF("Raid amount provided, setting to %d", count)
 	}
+	if cal == nil {
+		return "The raid calendar is not connected"
+	}
 	format := "Mon Jan 2 3:04 PM MST"
 	if count > 10 {
 		count = 10
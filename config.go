@@ -32,97 +32,208 @@ type Configuration struct {
 	RedirectURIs            []string  `json:"redirect_uris"`               // Google Redirect URIs
 	SQLConnectionString     string    `json:"SQLConnectionString"`         // user:pass@/db
 	// --------
-	DKPSheetURL                string   `json:"DKPSheetURL"`                // String after https://docs.google.com/spreadsheets/d/ and before /edit
-	DKPSheetName               string   `json:"DKPSheetName"`               // Sheet Name for DKP
-	DKPSheetClassCol           int      `json:"DKPSheetClassRow"`           // Column for player class BASE 0
-	DKPSheetRankCol            int      `json:"DKPSheetRankCol"`            // Column for player rank BASE 0
-	DKPSheetNameCol            int      `json:"DKPSheetNameCol"`            // Column for player name BASE 0
-	DKPSheetLevelCol           int      `json:"DKPSheetLevelCol"`           // Column for player level BASE 0
-	DKPSheetLastRaidCol        int      `json:"DKPSheetLastRaidCol"`        // Column for player last raid attended BASE 0
-	DKPSheetAttendanceCol      int      `json:"DKPSheetAttendanceCol"`      // Column for player attendance ratio Base 0
-	DKPSheetDKPCol             int      `json:"DKPSheetDKPCol"`             // Column for player DKP
-	DKPSummarySheetName        string   `json:"DKPSummarySheetName"`        // Sheet Name for DKP Summary
-	DKPSummarySheetDateCol     int      `json:"DKPSummarySheetDateCol"`     // Column for DKP Summary Date
-	DKPSummarySheetPlayerCol   int      `json:"DKPSummarySheetPlayerCol"`   // Column for DKP Summary player
-	DKPSummarySheetDKPDescCol  int      `json:"DKPSummarySheetDKPDescCol"`  // Column for DKP Description
-	DKPSummarySheetDKPCol      int      `json:"DKPSummarySheetDKPCol"`      // Column for DKP Summary DKP
-	DKPSRosterSheetName        string   `json:"DKPSRosterSheetName"`        // Sheet Name for DKP Rsoter
-	DKPSRosterSheetPlayerCol   int      `json:"DKPSRosterSheetPlayerCol"`   // Column for DKP Summary Date
-	DKPSRosterSheetLevelCol    int      `json:"DKPSRosterSheetLevelCol"`    // Column for DKP Summary player
-	DKPSRosterSheetClassCol    int      `json:"DKPSRosterSheetClassCol"`    // Column for DKP Description
-	DKPSRosterSheetRankCol     int      `json:"DKPSRosterSheetRankCol"`     // Column for DKP Summary DKP
-	DKPSRosterSheetJoinDateCol int      `json:"DKPSRosterSheetJoinDateCol"` // Column for DKP Summary DKP
-	SpellSheet                 string   `json:"SpellSheet"`                 // Sheet Name for Spells
-	SpellSheetHeaderRow        int      `json:"SpellSheetHeaderRow"`        // Row # for Spell Sheet's header containing player named BASE 0
-	SpellSheetSpellCol         int      `json:"SpellSheetSpellCol"`         // Column for spell names
-	RulesSheetName             string   `json:"RulesSheetName"`             // Sheet Name for Rules
-	GuildID                    string   `json:"GuildID"`                    // Discord Guild ID
-	PrivRoles                  []string `json:"PrivRoles"`                  // Array of roles that can run piviledged commands Exact String Match
-	NoPrivResponse             string   `json:"NoPrivResponse"`             // Response given if the user attempts a priv command unpriv
-	MaxMessageLength           int      `json:"MaxMessageLength"`           // Max Discord message length (2000)
-	KronoAPIURL                string   `json:"KronoAPIURL"`                // Aradune Auctions krono API URL
-	CommDKPCommand             string   `json:"CommDKPCommand"`             // String to trigger for DKP Command
-	CommDKPHelp                string   `json:"CommDKPHelp"`                // Aradune Auctions krono API URL
-	CommDKPDMOnly              bool     `json:"CommDKPDMOnly"`              // Aradune Auctions krono API URL
-	CommDKPPriv                bool     `json:"CommDKPPriv"`                // Aradune Auctions krono API URL
-	CommDKPHidden              bool     `json:"CommDKPHidden"`              // Aradune Auctions krono API URL
-	CommRaidSummaryCommand     string   `json:"CommRaidSummaryCommand"`     // Aradune Auctions krono API URL
-	CommRaidSummaryHelp        string   `json:"CommRaidSummaryHelp"`        // Aradune Auctions krono API URL
-	CommRaidSummaryDMOnly      bool     `json:"CommRaidSummaryDMOnly"`      // Aradune Auctions krono API URL
-	CommRaidSummaryPriv        bool     `json:"CommRaidSummaryPriv"`        // Aradune Auctions krono API URL
-	CommRaidSummaryHidden      bool     `json:"CommRaidSummaryHidden"`
-	CommHelpCommand            string   `json:"CommHelpCommand"` // Aradune Auctions krono API URL
-	CommHelpHelp               string   `json:"CommHelpHelp"`    // Aradune Auctions krono API URL
-	CommHelpDMOnly             bool     `json:"CommHelpDMOnly"`  // Aradune Auctions krono API URL
-	CommHelpPriv               bool     `json:"CommHelpPriv"`    // Aradune Auctions krono API URL
-	CommHelpHidden             bool     `json:"CommHelpHidden"`
-	CommDBRCommand             string   `json:"CommDBRCommand"` // Aradune Auctions krono API URL
-	CommDBRHelp                string   `json:"CommDBRHelp"`    // Aradune Auctions krono API URL
-	CommDBRDMOnly              bool     `json:"CommDBRDMOnly"`  // Aradune Auctions krono API URL
-	CommDBRPriv                bool     `json:"CommDBRPriv"`    // Aradune Auctions krono API URL
-	CommDBRHidden              bool     `json:"CommDBRHidden"`
-	CommKronoCommand           string   `json:"CommKronoCommand"` // Aradune Auctions krono API URL
-	CommKronoHelp              string   `json:"CommKronoHelp"`    // Aradune Auctions krono API URL
-	CommKronoDMOnly            bool     `json:"CommKronoDMOnly"`  // Aradune Auctions krono API URL
-	CommKronoPriv              bool     `json:"CommKronoPriv"`    // Aradune Auctions krono API URL
-	CommKronoHidden            bool     `json:"CommKronoHidden"`
-	CommSpellCommand           string   `json:"CommSpellCommand"` // Aradune Auctions krono API URL
-	CommSpellHelp              string   `json:"CommSpellHelp"`    // Aradune Auctions krono API URL
-	CommSpellDMOnly            bool     `json:"CommSpellDMOnly"`  // Aradune Auctions krono API URL
-	CommSpellPriv              bool     `json:"CommSpellPriv"`    // Aradune Auctions krono API URL
-	CommSpellHidden            bool     `json:"CommSpellHidden"`
-	CommGiveSpellCommand       string   `json:"CommGiveSpellCommand"` // Aradune Auctions krono API URL
-	CommGiveSpellHelp          string   `json:"CommGiveSpellHelp"`    // Aradune Auctions krono API URL
-	CommGiveSpellDMOnly        bool     `json:"CommGiveSpellDMOnly"`  // Aradune Auctions krono API URL
-	CommGiveSpellPriv          bool     `json:"CommGiveSpellPriv"`    // Aradune Auctions krono API URL
-	CommGiveSpellHidden        bool     `json:"CommGiveSpellHidden"`
-	CommRulesCommand           string   `json:"CommRulesCommand"` // Aradune Auctions krono API URL
-	CommRulesHelp              string   `json:"CommRulesHelp"`    // Aradune Auctions krono API URL
-	CommRulesDMOnly            bool     `json:"CommRulesDMOnly"`  // Aradune Auctions krono API URL
-	CommRulesPriv              bool     `json:"CommRulesPriv"`    // Aradune Auctions krono API URL
-	CommRulesHidden            bool     `json:"CommRulesHidden"`
-	CommDKPClassCommand        string   `json:"CommDKPClassCommand"` // Aradune Auctions krono API URL
-	CommDKPClassHelp           string   `json:"CommDKPClassHelp"`    // Aradune Auctions krono API URL
-	CommDKPClassDMOnly         bool     `json:"CommDKPClassDMOnly"`  // Aradune Auctions krono API URL
-	CommDKPClassPriv           bool     `json:"CommDKPClassPriv"`    // Aradune Auctions krono API URL
-	CommDKPClassHidden         bool     `json:"CommDKPClassHidden"`  // Is the DKP by class command hidden
-	RaidGCAL                   string   `json:"RaidGCAL"`            // string for the raiding google calendar
-	CommRaidCalCommand         string   `json:"CommRaidCalCommand"`  // Aradune Auctions krono API URL
-	CommRaidCalHelp            string   `json:"CommRaidCalHelp"`     // Aradune Auctions krono API URL
-	CommRaidCalDMOnly          bool     `json:"CommRaidCalDMOnly"`   // Aradune Auctions krono API URL
-	CommRaidCalPriv            bool     `json:"CommRaidCalPriv"`     // Aradune Auctions krono API URL
-	CommRaidCalHidden          bool     `json:"CommRaidCalHidden"`   // Is the DKP by class command hidden
-	RaidGCALLink               string   `json:"RaidGCALLink"`        // URL to gcal for people to add
-	CommDKPTenCommand          string   `json:"CommDKPTenCommand"`   // Aradune Auctions krono API URL
-	CommDKPTenHelp             string   `json:"CommDKPTenHelp"`      // Aradune Auctions krono API URL
-	CommDKPTenDMOnly           bool     `json:"CommDKPTenDMOnly"`    // Aradune Auctions krono API URL
-	CommDKPTenPriv             bool     `json:"CommDKPTenPriv"`      // Aradune Auctions krono API URL
-	CommDKPTenHidden           bool     `json:"CommDKPTenHidden"`    // Is the DKP by class command hidden
-}
-
-func init() {
-	readConfig()
-	log.Printf("Configuration loaded:\n %+v\n", configuration)
+	DKPSheetURL            string              `json:"DKPSheetURL"`            // String after https://docs.google.com/spreadsheets/d/ and before /edit
+	DKPSheetName           string              `json:"DKPSheetName"`           // Sheet Name for DKP
+	DKPSummarySheetName    string              `json:"DKPSummarySheetName"`    // Sheet Name for DKP Summary
+	DKPSRosterSheetName    string              `json:"DKPSRosterSheetName"`    // Sheet Name for DKP Rsoter
+	SpellSheet             string              `json:"SpellSheet"`             // Sheet Name for Spells
+	SpellSheetHeaderRow    int                 `json:"SpellSheetHeaderRow"`    // Row # for Spell Sheet's header containing player named BASE 0
+	SpellSheetSpellCol     int                 `json:"SpellSheetSpellCol"`     // Column for spell names
+	RulesSheetName         string              `json:"RulesSheetName"`         // Sheet Name for Rules
+	GuildID                string              `json:"GuildID"`                // Discord Guild ID
+	PrivRoles              []string            `json:"PrivRoles"`              // Array of roles that can run piviledged commands Exact String Match
+	NoPrivResponse         string              `json:"NoPrivResponse"`         // Response given if the user attempts a priv command unpriv
+	MaxMessageLength       int                 `json:"MaxMessageLength"`       // Max Discord message length (2000)
+	KronoAPIURL            string              `json:"KronoAPIURL"`            // Aradune Auctions krono API URL
+	CommDKPCommand         string              `json:"CommDKPCommand"`         // String to trigger for DKP Command
+	CommDKPHelp            string              `json:"CommDKPHelp"`            // Aradune Auctions krono API URL
+	CommDKPDMOnly          bool                `json:"CommDKPDMOnly"`          // Aradune Auctions krono API URL
+	CommDKPPriv            bool                `json:"CommDKPPriv"`            // Aradune Auctions krono API URL
+	CommDKPHidden          bool                `json:"CommDKPHidden"`          // Aradune Auctions krono API URL
+	CommRaidSummaryCommand string              `json:"CommRaidSummaryCommand"` // Aradune Auctions krono API URL
+	CommRaidSummaryHelp    string              `json:"CommRaidSummaryHelp"`    // Aradune Auctions krono API URL
+	CommRaidSummaryDMOnly  bool                `json:"CommRaidSummaryDMOnly"`  // Aradune Auctions krono API URL
+	CommRaidSummaryPriv    bool                `json:"CommRaidSummaryPriv"`    // Aradune Auctions krono API URL
+	CommRaidSummaryHidden  bool                `json:"CommRaidSummaryHidden"`
+	CommHelpCommand        string              `json:"CommHelpCommand"` // Aradune Auctions krono API URL
+	CommHelpHelp           string              `json:"CommHelpHelp"`    // Aradune Auctions krono API URL
+	CommHelpDMOnly         bool                `json:"CommHelpDMOnly"`  // Aradune Auctions krono API URL
+	CommHelpPriv           bool                `json:"CommHelpPriv"`    // Aradune Auctions krono API URL
+	CommHelpHidden         bool                `json:"CommHelpHidden"`
+	CommDBRCommand         string              `json:"CommDBRCommand"` // Aradune Auctions krono API URL
+	CommDBRHelp            string              `json:"CommDBRHelp"`    // Aradune Auctions krono API URL
+	CommDBRDMOnly          bool                `json:"CommDBRDMOnly"`  // Aradune Auctions krono API URL
+	CommDBRPriv            bool                `json:"CommDBRPriv"`    // Aradune Auctions krono API URL
+	CommDBRHidden          bool                `json:"CommDBRHidden"`
+	CommKronoCommand       string              `json:"CommKronoCommand"` // Aradune Auctions krono API URL
+	CommKronoHelp          string              `json:"CommKronoHelp"`    // Aradune Auctions krono API URL
+	CommKronoDMOnly        bool                `json:"CommKronoDMOnly"`  // Aradune Auctions krono API URL
+	CommKronoPriv          bool                `json:"CommKronoPriv"`    // Aradune Auctions krono API URL
+	CommKronoHidden        bool                `json:"CommKronoHidden"`
+	CommSpellCommand       string              `json:"CommSpellCommand"` // Aradune Auctions krono API URL
+	CommSpellHelp          string              `json:"CommSpellHelp"`    // Aradune Auctions krono API URL
+	CommSpellDMOnly        bool                `json:"CommSpellDMOnly"`  // Aradune Auctions krono API URL
+	CommSpellPriv          bool                `json:"CommSpellPriv"`    // Aradune Auctions krono API URL
+	CommSpellHidden        bool                `json:"CommSpellHidden"`
+	CommGiveSpellCommand   string              `json:"CommGiveSpellCommand"` // Aradune Auctions krono API URL
+	CommGiveSpellHelp      string              `json:"CommGiveSpellHelp"`    // Aradune Auctions krono API URL
+	CommGiveSpellDMOnly    bool                `json:"CommGiveSpellDMOnly"`  // Aradune Auctions krono API URL
+	CommGiveSpellPriv      bool                `json:"CommGiveSpellPriv"`    // Aradune Auctions krono API URL
+	CommGiveSpellHidden    bool                `json:"CommGiveSpellHidden"`
+	CommRulesCommand       string              `json:"CommRulesCommand"` // Aradune Auctions krono API URL
+	CommRulesHelp          string              `json:"CommRulesHelp"`    // Aradune Auctions krono API URL
+	CommRulesDMOnly        bool                `json:"CommRulesDMOnly"`  // Aradune Auctions krono API URL
+	CommRulesPriv          bool                `json:"CommRulesPriv"`    // Aradune Auctions krono API URL
+	CommRulesHidden        bool                `json:"CommRulesHidden"`
+	CommDKPClassCommand    string              `json:"CommDKPClassCommand"`    // Aradune Auctions krono API URL
+	CommDKPClassHelp       string              `json:"CommDKPClassHelp"`       // Aradune Auctions krono API URL
+	CommDKPClassDMOnly     bool                `json:"CommDKPClassDMOnly"`     // Aradune Auctions krono API URL
+	CommDKPClassPriv       bool                `json:"CommDKPClassPriv"`       // Aradune Auctions krono API URL
+	CommDKPClassHidden     bool                `json:"CommDKPClassHidden"`     // Is the DKP by class command hidden
+	RaidGCAL               string              `json:"RaidGCAL"`               // string for the raiding google calendar
+	CommRaidCalCommand     string              `json:"CommRaidCalCommand"`     // Aradune Auctions krono API URL
+	CommRaidCalHelp        string              `json:"CommRaidCalHelp"`        // Aradune Auctions krono API URL
+	CommRaidCalDMOnly      bool                `json:"CommRaidCalDMOnly"`      // Aradune Auctions krono API URL
+	CommRaidCalPriv        bool                `json:"CommRaidCalPriv"`        // Aradune Auctions krono API URL
+	CommRaidCalHidden      bool                `json:"CommRaidCalHidden"`      // Is the DKP by class command hidden
+	RaidGCALLink           string              `json:"RaidGCALLink"`           // URL to gcal for people to add
+	CommDKPTenCommand      string              `json:"CommDKPTenCommand"`      // Aradune Auctions krono API URL
+	CommDKPTenHelp         string              `json:"CommDKPTenHelp"`         // Aradune Auctions krono API URL
+	CommDKPTenDMOnly       bool                `json:"CommDKPTenDMOnly"`       // Aradune Auctions krono API URL
+	CommDKPTenPriv         bool                `json:"CommDKPTenPriv"`         // Aradune Auctions krono API URL
+	CommDKPTenHidden       bool                `json:"CommDKPTenHidden"`       // Is the DKP by class command hidden
+	CommResistsCommand     string              `json:"CommResistsCommand"`     // String to trigger the mob resists command
+	CommResistsHelp        string              `json:"CommResistsHelp"`        // Help text for the mob resists command
+	CommResistsDMOnly      bool                `json:"CommResistsDMOnly"`      // Is the mob resists command DM only
+	CommResistsPriv        bool                `json:"CommResistsPriv"`        // Is the mob resists command priviledged
+	CommResistsHidden      bool                `json:"CommResistsHidden"`      // Is the mob resists command hidden
+	CommItemCommand        string              `json:"CommItemCommand"`        // String to trigger the item lookup command
+	CommItemHelp           string              `json:"CommItemHelp"`           // Help text for the item lookup command
+	CommItemDMOnly         bool                `json:"CommItemDMOnly"`         // Is the item lookup command DM only
+	CommItemPriv           bool                `json:"CommItemPriv"`           // Is the item lookup command priviledged
+	CommItemHidden         bool                `json:"CommItemHidden"`         // Is the item lookup command hidden
+	CommSpellInfoCommand   string              `json:"CommSpellInfoCommand"`   // String to trigger the spell info command
+	CommSpellInfoHelp      string              `json:"CommSpellInfoHelp"`      // Help text for the spell info command
+	CommSpellInfoDMOnly    bool                `json:"CommSpellInfoDMOnly"`    // Is the spell info command DM only
+	CommSpellInfoPriv      bool                `json:"CommSpellInfoPriv"`      // Is the spell info command priviledged
+	CommSpellInfoHidden    bool                `json:"CommSpellInfoHidden"`    // Is the spell info command hidden
+	CommDropsCommand       string              `json:"CommDropsCommand"`       // String to trigger the item drops command
+	CommDropsHelp          string              `json:"CommDropsHelp"`          // Help text for the item drops command
+	CommDropsDMOnly        bool                `json:"CommDropsDMOnly"`        // Is the item drops command DM only
+	CommDropsPriv          bool                `json:"CommDropsPriv"`          // Is the item drops command priviledged
+	CommDropsHidden        bool                `json:"CommDropsHidden"`        // Is the item drops command hidden
+	CommLootCommand        string              `json:"CommLootCommand"`        // String to trigger the mob loot table command
+	CommLootHelp           string              `json:"CommLootHelp"`           // Help text for the mob loot table command
+	CommLootDMOnly         bool                `json:"CommLootDMOnly"`         // Is the mob loot table command DM only
+	CommLootPriv           bool                `json:"CommLootPriv"`           // Is the mob loot table command priviledged
+	CommLootHidden         bool                `json:"CommLootHidden"`         // Is the mob loot table command hidden
+	CommSpawnCommand       string              `json:"CommSpawnCommand"`       // String to trigger the mob spawn command
+	CommSpawnHelp          string              `json:"CommSpawnHelp"`          // Help text for the mob spawn command
+	CommSpawnDMOnly        bool                `json:"CommSpawnDMOnly"`        // Is the mob spawn command DM only
+	CommSpawnPriv          bool                `json:"CommSpawnPriv"`          // Is the mob spawn command priviledged
+	CommSpawnHidden        bool                `json:"CommSpawnHidden"`        // Is the mob spawn command hidden
+	TimersPath             string              `json:"TimersPath"`             // Where raid target timers were saved before the datastore (timers.json)
+	TimerChannelID         string              `json:"TimerChannelID"`         // Discord channel to announce open spawn windows in
+	CommKilledCommand      string              `json:"CommKilledCommand"`      // String to trigger the raid target kill command
+	CommKilledHelp         string              `json:"CommKilledHelp"`         // Help text for the raid target kill command
+	CommKilledDMOnly       bool                `json:"CommKilledDMOnly"`       // Is the raid target kill command DM only
+	CommKilledPriv         bool                `json:"CommKilledPriv"`         // Is the raid target kill command priviledged
+	CommKilledHidden       bool                `json:"CommKilledHidden"`       // Is the raid target kill command hidden
+	CommTimersCommand      string              `json:"CommTimersCommand"`      // String to trigger the raid target timers command
+	CommTimersHelp         string              `json:"CommTimersHelp"`         // Help text for the raid target timers command
+	CommTimersDMOnly       bool                `json:"CommTimersDMOnly"`       // Is the raid target timers command DM only
+	CommTimersPriv         bool                `json:"CommTimersPriv"`         // Is the raid target timers command priviledged
+	CommTimersHidden       bool                `json:"CommTimersHidden"`       // Is the raid target timers command hidden
+	StorePath              string              `json:"StorePath"`              // Where the datastore holding bot state is saved (store.json)
+	AuditPath              string              `json:"AuditPath"`              // Where the command audit log is appended to (audit.log)
+	CommIAmCommand         string              `json:"CommIAmCommand"`         // String to trigger the character link command
+	CommIAmHelp            string              `json:"CommIAmHelp"`            // Help text for the character link command
+	CommIAmDMOnly          bool                `json:"CommIAmDMOnly"`          // Is the character link command DM only
+	CommIAmPriv            bool                `json:"CommIAmPriv"`            // Is the character link command priviledged
+	CommIAmHidden          bool                `json:"CommIAmHidden"`          // Is the character link command hidden
+	CommLinkApproveCommand string              `json:"CommLinkApproveCommand"` // String to trigger the character link approval command
+	CommLinkApproveHelp    string              `json:"CommLinkApproveHelp"`    // Help text for the character link approval command
+	CommLinkApproveDMOnly  bool                `json:"CommLinkApproveDMOnly"`  // Is the character link approval command DM only
+	CommLinkApprovePriv    bool                `json:"CommLinkApprovePriv"`    // Is the character link approval command priviledged
+	CommLinkApproveHidden  bool                `json:"CommLinkApproveHidden"`  // Is the character link approval command hidden
+	CommAltsCommand        string              `json:"CommAltsCommand"`        // String to trigger the linked characters command
+	CommAltsHelp           string              `json:"CommAltsHelp"`           // Help text for the linked characters command
+	CommAltsDMOnly         bool                `json:"CommAltsDMOnly"`         // Is the linked characters command DM only
+	CommAltsPriv           bool                `json:"CommAltsPriv"`           // Is the linked characters command priviledged
+	CommAltsHidden         bool                `json:"CommAltsHidden"`         // Is the linked characters command hidden
+	DKPAltPolicy           string              `json:"DKPAltPolicy"`           // separate (default) gives alts their own DKP, combined adds them to their main's
+	SheetRefreshMinutes    int                 `json:"SheetRefreshMinutes"`    // Minutes between background reads of the roster and DKP sheets (5)
+	CommRefreshCommand     string              `json:"CommRefreshCommand"`     // String to trigger the DKP data refresh command
+	CommRefreshHelp        string              `json:"CommRefreshHelp"`        // Help text for the DKP data refresh command
+	CommRefreshDMOnly      bool                `json:"CommRefreshDMOnly"`      // Is the DKP data refresh command DM only
+	CommRefreshHidden      bool                `json:"CommRefreshHidden"`      // Is the DKP data refresh command hidden
+	DataSource             string              `json:"DataSource"`             // Where the roster, DKP, summary, spell and rules sheets live: google (default) or csv
+	DataSourcePath         string              `json:"DataSourcePath"`         // Directory of <sheet name>.csv files for the csv data source, spell sheets go in spells/<class>.csv
+	ColumnAliases          map[string][]string `json:"ColumnAliases"`          // Extra headers for sheet columns, keyed by sheet.column like "dkp.attendance"
+	CommLedgerCommand      string              `json:"CommLedgerCommand"`      // String to trigger the DKP ledger command
+	CommLedgerHelp         string              `json:"CommLedgerHelp"`         // Help text for the DKP ledger command
+	CommLedgerDMOnly       bool                `json:"CommLedgerDMOnly"`       // Is the DKP ledger command DM only
+	CommLedgerPriv         bool                `json:"CommLedgerPriv"`         // Is the DKP ledger command priviledged
+	CommLedgerHidden       bool                `json:"CommLedgerHidden"`       // Is the DKP ledger command hidden
+	LedgerExportSheetName  string              `json:"LedgerExportSheetName"`  // Sheet the ledger exports balances to in the DKP sheet layout (Ledger)
+	LedgerAttendanceDays   int                 `json:"LedgerAttendanceDays"`   // Days of ticks counted towards ledger attendance (30)
+	CommAuctionCommand     string              `json:"CommAuctionCommand"`     // String to trigger the start auction command
+	CommAuctionHelp        string              `json:"CommAuctionHelp"`        // Help text for the start auction command
+	CommAuctionDMOnly      bool                `json:"CommAuctionDMOnly"`      // Is the start auction command DM only
+	CommAuctionHidden      bool                `json:"CommAuctionHidden"`      // Is the start auction command hidden
+	CommBidCommand         string              `json:"CommBidCommand"`         // String to trigger the auction bid command
+	CommBidHelp            string              `json:"CommBidHelp"`            // Help text for the auction bid command
+	CommBidDMOnly          bool                `json:"CommBidDMOnly"`          // Is the auction bid command DM only
+	CommBidPriv            bool                `json:"CommBidPriv"`            // Is the auction bid command priviledged
+	CommBidHidden          bool                `json:"CommBidHidden"`          // Is the auction bid command hidden
+	AuctionMinutes         int                 `json:"AuctionMinutes"`         // Default length of an auction in minutes (3)
+	AuctionBidMode         string              `json:"AuctionBidMode"`         // open to bid in the auction channel, dm or sealed to bid privately (open)
+	AuctionRankPriority    []string            `json:"AuctionRankPriority"`    // Ranks in the order they win tied bids, unlisted ranks come last
+	AuctionPricingRule     string              `json:"AuctionPricingRule"`     // What auction winners pay: first, vickrey, tier or rankcaps (first)
+	AuctionMinimumBid      int                 `json:"AuctionMinimumBid"`      // Smallest bid allowed (1)
+	ItemTiers              map[string]string   `json:"ItemTiers"`              // Item name to tier for tier pricing, unlisted items are "default"
+	TierCosts              map[string]int      `json:"TierCosts"`              // DKP cost of each item tier for tier pricing
+	RankBidCaps            map[string]int      `json:"RankBidCaps"`            // Most a rank can bid as a percentage of their DKP for rankcaps pricing
+	CommAwardCommand       string              `json:"CommAwardCommand"`       // String to trigger the loot award command
+	CommAwardHelp          string              `json:"CommAwardHelp"`          // Help text for the loot award command
+	CommAwardDMOnly        bool                `json:"CommAwardDMOnly"`        // Is the loot award command DM only
+	CommAwardHidden        bool                `json:"CommAwardHidden"`        // Is the loot award command hidden
+	AwardBatchSeconds      int                 `json:"AwardBatchSeconds"`      // Seconds without an award before awards are written to the summary sheet together (30)
+	CommHistoryCommand     string              `json:"CommHistoryCommand"`     // String to trigger the DKP history command
+	CommHistoryHelp        string              `json:"CommHistoryHelp"`        // Help text for the DKP history command
+	CommHistoryDMOnly      bool                `json:"CommHistoryDMOnly"`      // Is the DKP history command DM only
+	CommHistoryPriv        bool                `json:"CommHistoryPriv"`        // Is the DKP history command priviledged
+	CommHistoryHidden      bool                `json:"CommHistoryHidden"`      // Is the DKP history command hidden
+	CommAttendanceCommand  string              `json:"CommAttendanceCommand"`  // String to trigger the attendance command
+	CommAttendanceHelp     string              `json:"CommAttendanceHelp"`     // Help text for the attendance command
+	CommAttendanceDMOnly   bool                `json:"CommAttendanceDMOnly"`   // Is the attendance command DM only
+	CommAttendancePriv     bool                `json:"CommAttendancePriv"`     // Is the attendance command priviledged
+	CommAttendanceHidden   bool                `json:"CommAttendanceHidden"`   // Is the attendance command hidden
+	AttendanceThreshold    int                 `json:"AttendanceThreshold"`    // Attendance percentage needed to be loot eligible, 0 to not flag anyone
+	CommDecayCommand       string              `json:"CommDecayCommand"`       // String to trigger the decay command
+	CommDecayHelp          string              `json:"CommDecayHelp"`          // Help text for the decay command
+	CommDecayDMOnly        bool                `json:"CommDecayDMOnly"`        // Is the decay command DM only
+	CommDecayPriv          bool                `json:"CommDecayPriv"`          // Is the decay command priviledged
+	CommDecayHidden        bool                `json:"CommDecayHidden"`        // Is the decay command hidden
+	DecayPercent           int                 `json:"DecayPercent"`           // Percentage of DKP taken by decay, 0 to not decay
+	DecayFloor             int                 `json:"DecayFloor"`             // Decay never takes anyone below this much DKP
+	DKPCap                 int                 `json:"DKPCap"`                 // Most DKP anyone can hold after decay, 0 for no cap
+	DecaySchedule          string              `json:"DecaySchedule"`          // When decay runs, daily or a weekday and a time like "tuesday 04:00", empty to only run it by hand
+	DecayChannelID         string              `json:"DecayChannelID"`         // Channel to announce scheduled decay in
+	CommGroupsCommand      string              `json:"CommGroupsCommand"`      // String to trigger the class groups command
+	CommGroupsHelp         string              `json:"CommGroupsHelp"`         // Help text for the class groups command
+	CommGroupsDMOnly       bool                `json:"CommGroupsDMOnly"`       // Is the class groups command DM only
+	CommGroupsPriv         bool                `json:"CommGroupsPriv"`         // Is the class groups command priviledged
+	CommGroupsHidden       bool                `json:"CommGroupsHidden"`       // Is the class groups command hidden
+	ClassGroups            map[string][]string `json:"ClassGroups"`            // Class groups added to or replacing the ones in classgroups.json, members can be classes, aliases or other groups
+	ClassAliases           map[string]string   `json:"ClassAliases"`           // Class abbreviations added to or replacing the ones in classgroups.json, like "sk": "shadow knight"
+	CommWhoCanCommand      string              `json:"CommWhoCanCommand"`      // String to trigger the who can use command
+	CommWhoCanHelp         string              `json:"CommWhoCanHelp"`         // Help text for the who can use command
+	CommWhoCanDMOnly       bool                `json:"CommWhoCanDMOnly"`       // Is the who can use command DM only
+	CommWhoCanPriv         bool                `json:"CommWhoCanPriv"`         // Is the who can use command priviledged
+	CommWhoCanHidden       bool                `json:"CommWhoCanHidden"`       // Is the who can use command hidden
+	LootIneligibleRanks    []string            `json:"LootIneligibleRanks"`    // Ranks that can't take loot, like Recruit
+	PlainTextResponses     bool                `json:"PlainTextResponses"`     // Answer in plain text instead of embeds
 }
 
 func readConfig() error {
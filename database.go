@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// gameDB is the global pooled connection to the EQEmu database
+var gameDB *sql.DB
+
+// gameDBMutex guards connecting and reconnecting gameDB
+var gameDBMutex sync.Mutex
+
+// errGameDBUnavailable is returned to users when the EQEmu database can't be reached
+var errGameDBUnavailable = errors.New("game database unavailable")
+
+func connectDB() (*sql.DB, error) {
+	l := LogInit("connectDB-database.go")
+	defer l.End()
+	if configuration.SQLConnectionString == "" {
+		return nil, errors.New("SQLConnectionString is not configured")
+	}
+	db, err := sql.Open("mysql", configuration.SQLConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	// Open doesn't open a connection. Validate DSN data:
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	db.SetConnMaxLifetime(time.Minute * 3)
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(10)
+	l.InfoF("Connected to the game database")
+	return db, nil
+}
+
+// getDB returns the shared database handle, connecting the first time it's needed.
+// database/sql reconnects pooled connections itself, gameDBFailed drops the handle when
+// the database really went away
+func getDB() (*sql.DB, error) {
+	l := LogInit("getDB-database.go")
+	defer l.End()
+	gameDBMutex.Lock()
+	defer gameDBMutex.Unlock()
+	if gameDB != nil {
+		return gameDB, nil
+	}
+	db, err := connectDB()
+	if err != nil {
+		l.ErrorF("Unable to connect to the game database: %s", err.Error())
+		return nil, errGameDBUnavailable
+	}
+	gameDB = db
+	return gameDB, nil
+}
+
+// gameDBFailed is called after a query fails, if the database can't be reached the
+// handle is dropped so the next getDB connects again. It returns the error for users
+func gameDBFailed(err error) error {
+	l := LogInit("gameDBFailed-database.go")
+	defer l.End()
+	gameDBMutex.Lock()
+	defer gameDBMutex.Unlock()
+	if gameDB == nil {
+		return errGameDBUnavailable
+	}
+	if pingErr := gameDB.Ping(); pingErr != nil {
+		l.WarnF("Game database ping failed after %s, reconnecting next time: %s", err.Error(), pingErr.Error())
+		gameDB.Close()
+		gameDB = nil
+	}
+	return errGameDBUnavailable
+}
+
+func closeDB() {
+	gameDBMutex.Lock()
+	defer gameDBMutex.Unlock()
+	if gameDB != nil {
+		gameDB.Close()
+		gameDB = nil
+	}
+}
+
+// likeEscaper escapes LIKE wildcards so user input matches literally, queries using it
+// need ESCAPE '\\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeContains is a LIKE pattern matching names that contain s
+func likeContains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestLikeContains(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Lord_Nagafen", `%Lord\_Nagafen%`},
+		{"100%", `%100\%%`},
+		{`a\b`, `%a\\b%`},
+		{"Vox", "%Vox%"},
+		{"", "%%"},
+	}
+	for _, tt := range tests {
+		if got := likeContains(tt.name); got != tt.want {
+			t.Errorf("likeContains(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Data sources for the DataSource setting
+const (
+	dataSourceGoogle = "google" // Google Sheets, the default
+	dataSourceCSV    = "csv"    // a directory of CSV files, one per sheet
+)
+
+// csvSpellDir is the subdirectory of a csv data source holding one spell sheet per class
+const csvSpellDir = "spells"
+
+// DataSource is where the guild's roster, DKP, summary, spells and rules are kept.
+// Every sheet is returned as rows of cells exactly like the Sheets API returns them
+type DataSource interface {
+	Roster() ([][]interface{}, error)
+	DKP() ([][]interface{}, error)
+	Summary() ([][]interface{}, error)
+	Spells(class string) ([][]interface{}, error)
+	Rules() ([][]interface{}, error)
+	SetSpell(class, cell, value string) error            // cell is A1 notation
+	WriteSheet(sheet string, rows [][]interface{}) error // replaces everything on a DKP sheet
+	AppendSummary(rows [][]interface{}) error            // adds rows to the bottom of the summary sheet
+}
+
+// dataSource is the global the DKP, spell and rules commands read from
+var dataSource DataSource
+
+// newDataSource builds the configured data source
+func newDataSource() (DataSource, error) {
+	switch strings.ToLower(configuration.DataSource) {
+	case "", dataSourceGoogle:
+		if srv == nil {
+			return nil, errors.New("google sheets is not connected")
+		}
+		return &googleSheets{srv: srv}, nil
+	case dataSourceCSV:
+		if configuration.DataSourcePath == "" {
+			return nil, errors.New("DataSourcePath is not configured")
+		}
+		return &csvFiles{dir: configuration.DataSourcePath}, nil
+	}
+	return nil, fmt.Errorf("unknown DataSource %s", configuration.DataSource)
+}
+
+// googleSheets reads the guild's Google Sheets
+type googleSheets struct {
+	srv *sheets.Service
+}
+
+func (g *googleSheets) get(spreadsheetID, readRange string) ([][]interface{}, error) {
+	resp, err := g.srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Values, nil
+}
+
+func (g *googleSheets) Roster() ([][]interface{}, error) {
+	return g.get(configuration.DKPSheetURL, configuration.DKPSRosterSheetName)
+}
+
+func (g *googleSheets) DKP() ([][]interface{}, error) {
+	return g.get(configuration.DKPSheetURL, configuration.DKPSheetName)
+}
+
+func (g *googleSheets) Summary() ([][]interface{}, error) {
+	return g.get(configuration.DKPSheetURL, configuration.DKPSummarySheetName)
+}
+
+func (g *googleSheets) Spells(class string) ([][]interface{}, error) {
+	return g.get(configuration.SpellSheet, class)
+}
+
+func (g *googleSheets) Rules() ([][]interface{}, error) {
+	return g.get(configuration.SpellSheet, configuration.RulesSheetName)
+}
+
+func (g *googleSheets) SetSpell(class, cell, value string) error {
+	return writeToSheet(configuration.SpellSheet, class+"!"+cell, value)
+}
+
+// WriteSheet writes over the old rows before clearing whatever is left below them, so a
+// failed write never leaves the sheet empty
+func (g *googleSheets) WriteSheet(sheet string, rows [][]interface{}) error {
+	vr := sheets.ValueRange{Values: rows}
+	if _, err := g.srv.Spreadsheets.Values.Update(configuration.DKPSheetURL, sheet+"!A1", &vr).ValueInputOption("USER_ENTERED").Do(); err != nil {
+		return err
+	}
+	below := fmt.Sprintf("%s!A%d:ZZZ", sheet, len(rows)+1)
+	_, err := g.srv.Spreadsheets.Values.Clear(configuration.DKPSheetURL, below, &sheets.ClearValuesRequest{}).Do()
+	return err
+}
+
+func (g *googleSheets) AppendSummary(rows [][]interface{}) error {
+	return appendToSheet(configuration.DKPSheetURL, configuration.DKPSummarySheetName, rows)
+}
+
+// csvFiles reads sheets saved as CSV files named after the sheet, spell sheets are
+// kept in a spells directory named after the class
+type csvFiles struct {
+	dir string
+}
+
+func (c *csvFiles) path(sheet string) string {
+	return filepath.Join(c.dir, sheet+".csv")
+}
+
+func (c *csvFiles) read(path string) ([][]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1 // rows don't need to be the same length
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, len(records))
+	for i, record := range records {
+		rows[i] = make([]interface{}, len(record))
+		for j, cell := range record {
+			rows[i][j] = cell
+		}
+	}
+	return rows, nil
+}
+
+func (c *csvFiles) Roster() ([][]interface{}, error) {
+	return c.read(c.path(configuration.DKPSRosterSheetName))
+}
+
+func (c *csvFiles) DKP() ([][]interface{}, error) {
+	return c.read(c.path(configuration.DKPSheetName))
+}
+
+func (c *csvFiles) Summary() ([][]interface{}, error) {
+	return c.read(c.path(configuration.DKPSummarySheetName))
+}
+
+func (c *csvFiles) Spells(class string) ([][]interface{}, error) {
+	return c.read(c.path(filepath.Join(csvSpellDir, class)))
+}
+
+func (c *csvFiles) Rules() ([][]interface{}, error) {
+	return c.read(c.path(configuration.RulesSheetName))
+}
+
+func (c *csvFiles) SetSpell(class, cell, value string) error {
+	col, row, err := parseCell(cell)
+	if err != nil {
+		return err
+	}
+	path := c.path(filepath.Join(csvSpellDir, class))
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	f.Close()
+	if err != nil {
+		return err
+	}
+	for len(records) <= row {
+		records = append(records, []string{"", ""}) // a row of no or one empty cell is written as a blank line, which is skipped when read
+	}
+	for len(records[row]) <= col {
+		records[row] = append(records[row], "")
+	}
+	records[row][col] = value
+	return writeCSV(path, records)
+}
+
+func (c *csvFiles) WriteSheet(sheet string, rows [][]interface{}) error {
+	records := make([][]string, len(rows))
+	for i, row := range rows {
+		records[i] = make([]string, len(row))
+		for j, cell := range row {
+			records[i][j] = fmt.Sprintf("%v", cell)
+		}
+	}
+	return writeCSV(c.path(sheet), records)
+}
+
+func (c *csvFiles) AppendSummary(rows [][]interface{}) error {
+	path := c.path(configuration.DKPSummarySheetName)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(f)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = fmt.Sprintf("%v", cell)
+		}
+		if err := writer.Write(record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeCSV replaces the file at path with records
+func writeCSV(path string, records [][]string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(f)
+	if err := writer.WriteAll(records); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// parseCell converts A1 notation to a zero based column and row
+func parseCell(cell string) (int, int, error) {
+	cell = strings.ToUpper(strings.TrimSpace(cell))
+	col, i := 0, 0
+	for ; i < len(cell) && cell[i] >= 'A' && cell[i] <= 'Z'; i++ {
+		col = col*26 + int(cell[i]-'A') + 1
+	}
+	var row int
+	if _, err := fmt.Sscanf(cell[i:], "%d", &row); err != nil || col == 0 || row < 1 {
+		return 0, 0, fmt.Errorf("incorrect cell %s", cell)
+	}
+	return col - 1, row - 1, nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useCSVSheets points dataSource at a temp directory holding sheets, keyed by sheet name
+func useCSVSheets(t *testing.T, sheets map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range sheets {
+		path := filepath.Join(dir, name+".csv")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configuration.DKPSRosterSheetName = sheetRoster
+	configuration.DKPSheetName = sheetDKP
+	configuration.DKPSummarySheetName = sheetSummary
+	dataSource = &csvFiles{dir: dir}
+	return dir
+}
+
+// rowsOf builds sheet rows the way csvFiles reads them
+func rowsOf(records ...[]string) [][]interface{} {
+	rows := make([][]interface{}, len(records))
+	for i, record := range records {
+		rows[i] = make([]interface{}, len(record))
+		for j, cell := range record {
+			rows[i][j] = cell
+		}
+	}
+	return rows
+}
+
+func TestCSVRead(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want [][]interface{}
+	}{
+		{"plain", "Name,DKP\nMortimus,10\n", rowsOf([]string{"Name", "DKP"}, []string{"Mortimus", "10"})},
+		{"quoted cells", "\"Smith, Jr\",\"1,000\",\"said \"\"hi\"\"\"\n", rowsOf([]string{"Smith, Jr", "1,000", `said "hi"`})},
+		{"blank cells and short rows", "Name,,DKP\nMortimus\n,,\n", rowsOf([]string{"Name", "", "DKP"}, []string{"Mortimus"}, []string{"", "", ""})},
+		{"empty", "", rowsOf()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCSVSheets(t, map[string]string{sheetSummary: tt.csv})
+			rows, err := dataSource.Summary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("read %q, want %q", rows, tt.want)
+			}
+		})
+	}
+	useCSVSheets(t, nil)
+	if _, err := dataSource.Roster(); err == nil {
+		t.Error("reading a missing sheet didn't fail")
+	}
+}
+
+func TestCSVAppendSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		append   [][]interface{}
+		want     [][]interface{}
+	}{
+		{
+			name:     "appends below the existing rows",
+			existing: "Date,Name,Description,DKP\n01/02/2021,Alpha,Raid,5\n",
+			append:   [][]interface{}{{"01/03/2021", "Bravo", "Cloak of Flames, Epic", -30}, {"01/03/2021", "Charlie", "Raid", 5}},
+			want: rowsOf(
+				[]string{"Date", "Name", "Description", "DKP"},
+				[]string{"01/02/2021", "Alpha", "Raid", "5"},
+				[]string{"01/03/2021", "Bravo", "Cloak of Flames, Epic", "-30"},
+				[]string{"01/03/2021", "Charlie", "Raid", "5"},
+			),
+		},
+		{
+			name:   "creates a missing sheet",
+			append: [][]interface{}{{"01/03/2021", "Bravo", "Raid", 5}},
+			want:   rowsOf([]string{"01/03/2021", "Bravo", "Raid", "5"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheets := map[string]string{}
+			if tt.existing != "" {
+				sheets[sheetSummary] = tt.existing
+			}
+			useCSVSheets(t, sheets)
+			if err := dataSource.AppendSummary(tt.append); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := dataSource.Summary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("read back %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVWriteSheet(t *testing.T) {
+	dir := useCSVSheets(t, map[string]string{sheetDKP: "Name,DKP\nAlpha,10\nBravo,20\nCharlie,30\n"})
+	if err := dataSource.WriteSheet(sheetDKP, [][]interface{}{{"Name", "DKP"}, {"Delta, Jr", 40}}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := dataSource.DKP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rowsOf([]string{"Name", "DKP"}, []string{"Delta, Jr", "40"})
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("read back %q, want %q", rows, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, sheetDKP+".csv.tmp")); !os.IsNotExist(err) {
+		t.Errorf("the temp file was left behind: %v", err)
+	}
+}
+
+func TestCSVSetSpell(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		cell     string
+		value    string
+		want     [][]interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "replaces a cell",
+			existing: "Spell,Level,Have\nFireball,20,No\n",
+			cell:     "C2",
+			value:    "Yes",
+			want:     rowsOf([]string{"Spell", "Level", "Have"}, []string{"Fireball", "20", "Yes"}),
+		},
+		{
+			name:     "grows the sheet to reach the cell",
+			existing: "Spell\n",
+			cell:     "b3",
+			value:    "Yes, both",
+			want:     rowsOf([]string{"Spell"}, []string{"", ""}, []string{"", "Yes, both"}),
+		},
+		{
+			name:     "bad cell",
+			existing: "Spell\n",
+			cell:     "3B",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCSVSheets(t, map[string]string{filepath.Join(csvSpellDir, "wizard"): tt.existing})
+			err := dataSource.SetSpell("wizard", tt.cell, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := dataSource.Spells("wizard")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("read back %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCell(t *testing.T) {
+	tests := []struct {
+		cell     string
+		col, row int
+		wantErr  bool
+	}{
+		{cell: "A1", col: 0, row: 0},
+		{cell: "c12", col: 2, row: 11},
+		{cell: " Z3 ", col: 25, row: 2},
+		{cell: "AA1", col: 26, row: 0},
+		{cell: "AB10", col: 27, row: 9},
+		{cell: "12", wantErr: true},
+		{cell: "A", wantErr: true},
+		{cell: "A0", wantErr: true},
+		{cell: "", wantErr: true},
+		{cell: "   ", wantErr: true},
+		{cell: `"A1"`, wantErr: true},
+		{cell: "'B2'", wantErr: true},
+	}
+	for _, tt := range tests {
+		col, row, err := parseCell(tt.cell)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCell(%q) = %d, %d, expected an error", tt.cell, col, row)
+			}
+			continue
+		}
+		if err != nil || col != tt.col || row != tt.row {
+			t.Errorf("parseCell(%q) = %d, %d, %v, want %d, %d", tt.cell, col, row, err, tt.col, tt.row)
+		}
+	}
+}
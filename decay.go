@@ -0,0 +1,324 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// bucketDecay remembers when decay last ran so a restart doesn't run it twice
+const bucketDecay = "decay"
+
+// decayLastRunKey is the key of the last run in bucketDecay
+const decayLastRunKey = "lastRun"
+
+// decayDaily runs decay every day in DecaySchedule
+const decayDaily = "daily"
+
+// decayConfirm runs decay again in a period it already ran in
+const decayConfirm = "confirm"
+
+// decayRowTag starts the description of every decay and cap row on the summary sheet, so
+// attendance and history can tell them from raids and loot
+const decayRowTag = "Decay:"
+
+// unscheduledDecayPeriod is how long !decay apply asks for confirmation after a run when
+// there's no DecaySchedule
+const unscheduledDecayPeriod = 24 * time.Hour
+
+// decayMutex keeps a manual and a scheduled decay from applying at the same time
+var decayMutex sync.Mutex
+
+// errDecayRan is returned when decay ran while another run was waiting for decayMutex
+var errDecayRan = errors.New("decay ran while this run was waiting")
+
+// DecayAdjustment is what decay and the cap take from a player
+type DecayAdjustment struct {
+	player Player
+	decay  int // taken by DecayPercent, never below DecayFloor
+	capped int // taken to bring them down to DKPCap
+}
+
+// Decay previews or applies DKP decay and the DKP cap, decay also runs on DecaySchedule
+func Decay(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("Decay-decay.go")
+	defer l.End()
+	if len(message) < 2 {
+		return fmt.Sprintf("%s preview|apply [%s]", configuration.CommDecayCommand, decayConfirm)
+	}
+	switch strings.ToLower(message[1]) {
+	case "preview":
+		adjustments := decayAdjustments(lookupAllPlayer())
+		if len(adjustments) == 0 {
+			return "Decay would not change anyone's DKP"
+		}
+		response = fmt.Sprintf("%s\n", decayRules())
+		for _, adj := range adjustments {
+			response += adj.format()
+		}
+		return response + rosterAge()
+	case "apply":
+		if !isPriviledged(s, m.Author.ID) { // anyone can preview, only officers apply
+			l.WarnF("%s tried to apply decay without being priviledged", m.Author.Username)
+			return configuration.NoPrivResponse
+		}
+		confirmed := len(message) > 2 && strings.ToLower(message[2]) == decayConfirm
+		lastRun, ranThisPeriod := decayRanThisPeriod(time.Now())
+		if ranThisPeriod && !confirmed {
+			return fmt.Sprintf("Decay already ran %s, use %s apply %s to run it again", lastRun.Format(timerFormat), configuration.CommDecayCommand, decayConfirm)
+		}
+		count, err := applyDecayAdjustments(m.Author.Username, lastRun)
+		if err == errDecayRan {
+			return fmt.Sprintf("Decay just ran, use %s apply %s to run it again", configuration.CommDecayCommand, decayConfirm)
+		}
+		if err != nil {
+			l.ErrorF("Unable to apply decay: %s", err.Error())
+			return "Unable to write decay to the summary sheet"
+		}
+		l.InfoF("%s applied decay to %d players", m.Author.Username, count)
+		return fmt.Sprintf("Decay applied to %d players", count)
+	}
+	return fmt.Sprintf("%s preview|apply [%s]", configuration.CommDecayCommand, decayConfirm)
+}
+
+// decayRules describes the configured decay for previews
+func decayRules() string {
+	rules := fmt.Sprintf("%d%% decay down to %d", configuration.DecayPercent, configuration.DecayFloor)
+	if configuration.DKPCap > 0 {
+		rules = fmt.Sprintf("%s, capped at %d", rules, configuration.DKPCap)
+	}
+	return rules
+}
+
+// decayAdjustments works out what decay and the cap take from each player, highest DKP first
+func decayAdjustments(players []Player) []DecayAdjustment {
+	var adjustments []DecayAdjustment
+	for _, player := range players {
+		adj := DecayAdjustment{player: player}
+		adj.decay, adj.capped = decayAmounts(player.dkp)
+		if adj.decay > 0 || adj.capped > 0 {
+			adjustments = append(adjustments, adj)
+		}
+	}
+	sort.SliceStable(adjustments, func(i, j int) bool { return adjustments[i].player.dkp > adjustments[j].player.dkp })
+	return adjustments
+}
+
+// decayAmounts is what decay and then the cap take from a balance of dkp
+func decayAmounts(dkp int) (decay, capped int) {
+	if configuration.DecayPercent > 0 && dkp > configuration.DecayFloor {
+		decay = dkp * configuration.DecayPercent / 100
+		if dkp-decay < configuration.DecayFloor {
+			decay = dkp - configuration.DecayFloor
+		}
+	}
+	if remaining := dkp - decay; configuration.DKPCap > 0 && remaining > configuration.DKPCap {
+		capped = remaining - configuration.DKPCap
+	}
+	return decay, capped
+}
+
+func (da DecayAdjustment) format() string {
+	response := fmt.Sprintf("%s:\t%d", da.player.name, da.player.dkp)
+	if da.decay > 0 {
+		response = fmt.Sprintf("%s\tdecay -%d", response, da.decay)
+	}
+	if da.capped > 0 {
+		response = fmt.Sprintf("%s\tcap -%d", response, da.capped)
+	}
+	return fmt.Sprintf("%s\t= %d\n", response, da.player.dkp-da.decay-da.capped)
+}
+
+// isDecayRow returns true if a summary sheet description is a decay or cap row
+func isDecayRow(description string) bool {
+	return strings.HasPrefix(strings.TrimSpace(description), decayRowTag)
+}
+
+// decayRanThisPeriod returns when decay last ran if that was in the current DecaySchedule
+// period, or within unscheduledDecayPeriod when there's no schedule
+func decayRanThisPeriod(now time.Time) (time.Time, bool) {
+	lastRun, err := decayLastRun()
+	if err != nil || lastRun.IsZero() {
+		return time.Time{}, false
+	}
+	start, ok := lastScheduledDecay(now)
+	if !ok {
+		start = now.Add(-unscheduledDecayPeriod)
+	}
+	return lastRun, !lastRun.Before(start)
+}
+
+// decayLastRun is when decay last ran, zero if it never has
+func decayLastRun() (time.Time, error) {
+	var lastRun time.Time
+	_, err := store.Get(bucketDecay, decayLastRunKey, &lastRun)
+	return lastRun, err
+}
+
+// applyDecayAdjustments writes decay and cap rows to the summary sheet from fresh sheet data
+// and records decay of each ledger balance in the ledger, returning how many players changed.
+// lastRun is when the caller saw decay last run, if it has run since errDecayRan is returned
+func applyDecayAdjustments(recordedBy string, lastRun time.Time) (int, error) {
+	l := LogInit("applyDecayAdjustments-decay.go")
+	defer l.End()
+	decayMutex.Lock()
+	defer decayMutex.Unlock()
+	if ran, err := decayLastRun(); err != nil {
+		return 0, err
+	} else if ran.After(lastRun) {
+		return 0, errDecayRan
+	}
+	if err := refreshRoster(); err != nil {
+		return 0, err
+	}
+	adjustments := decayAdjustments(lookupAllPlayer())
+	now := time.Now()
+	var entries []SummaryRow
+	for _, adj := range adjustments {
+		if adj.decay > 0 {
+			entries = append(entries, SummaryRow{date: now, player: adj.player.name, description: decayDescription(), dkp: -adj.decay})
+		}
+		if adj.capped > 0 {
+			entries = append(entries, SummaryRow{date: now, player: adj.player.name, description: capDescription(), dkp: -adj.capped})
+		}
+	}
+	if len(entries) > 0 {
+		rows, err := summaryRows(entries)
+		if err != nil {
+			return 0, err
+		}
+		if err := dataSource.AppendSummary(rows); err != nil {
+			return 0, err
+		}
+		l.InfoF("Wrote %d decay rows to the summary sheet", len(rows))
+	}
+	err := store.Update(func(tx *StoreTx) error { // the ledger decays its own balances so they can always be replayed
+		accounts, err := ledgerAccounts(tx)
+		if err != nil {
+			return err
+		}
+		for _, account := range sortedAccounts(accounts) {
+			decay, capped := decayAmounts(account.dkp)
+			if decay > 0 {
+				if err := putLedgerEntry(tx, &LedgerEntry{Time: now, Kind: ledgerDecay, Character: account.name, Amount: -decay, Description: decayDescription(), Percent: configuration.DecayPercent, RecordedBy: recordedBy}); err != nil {
+					return err
+				}
+			}
+			if capped > 0 {
+				if err := putLedgerEntry(tx, &LedgerEntry{Time: now, Kind: ledgerDecay, Character: account.name, Amount: -capped, Description: capDescription(), RecordedBy: recordedBy}); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Put(bucketDecay, decayLastRunKey, now)
+	})
+	if err != nil { // the sheet has the rows, the ledger is now behind it
+		l.ErrorF("Decay is on the summary sheet but couldn't be recorded in the ledger: %s", err.Error())
+		if err := store.Put(bucketDecay, decayLastRunKey, now); err != nil { // at least don't run it again
+			l.ErrorF("Unable to save when decay ran: %s", err.Error())
+		}
+		return len(adjustments), err
+	}
+	return len(adjustments), nil
+}
+
+// decayDescription is the description of decay rows and ledger entries
+func decayDescription() string {
+	return fmt.Sprintf("%s %d%%", decayRowTag, configuration.DecayPercent)
+}
+
+// capDescription is the description of cap rows and ledger entries
+func capDescription() string {
+	return fmt.Sprintf("%s cap of %d", decayRowTag, configuration.DKPCap)
+}
+
+// lastScheduledDecay returns the latest time at or before now that DecaySchedule
+// ("daily 04:00" or "tuesday 04:00") says decay should run
+func lastScheduledDecay(now time.Time) (time.Time, bool) {
+	fields := strings.Fields(strings.ToLower(configuration.DecaySchedule))
+	if len(fields) != 2 {
+		return time.Time{}, false
+	}
+	at, err := time.ParseInLocation("15:04", fields[1], now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	run := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	if run.After(now) {
+		run = run.AddDate(0, 0, -1)
+	}
+	if fields[0] == decayDaily {
+		return run, true
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.ToLower(day.String()) == fields[0] {
+			for run.Weekday() != day {
+				run = run.AddDate(0, 0, -1)
+			}
+			return run, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// watchDecay applies decay on DecaySchedule, it never returns
+func watchDecay(s *discordgo.Session) {
+	l := LogInit("watchDecay-decay.go")
+	defer l.End()
+	if configuration.DecaySchedule == "" {
+		return
+	}
+	if _, ok := lastScheduledDecay(time.Now()); !ok {
+		l.ErrorF("Unable to understand DecaySchedule %s, use daily or a weekday and a time like tuesday 04:00", configuration.DecaySchedule)
+		return
+	}
+	var lastRun time.Time
+	found, err := store.Get(bucketDecay, decayLastRunKey, &lastRun)
+	if err != nil {
+		l.ErrorF("Unable to read when decay last ran: %s", err.Error())
+		return
+	}
+	if !found { // start from the next scheduled run, not one we missed before decay was set up
+		if err := store.Put(bucketDecay, decayLastRunKey, time.Now()); err != nil {
+			l.ErrorF("Unable to save when decay ran: %s", err.Error())
+		}
+	}
+	ticker := time.NewTicker(timerCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		checkDecay(s)
+	}
+}
+
+func checkDecay(s *discordgo.Session) {
+	l := LogInit("checkDecay-decay.go")
+	defer l.End()
+	due, _ := lastScheduledDecay(time.Now())
+	lastRun, err := decayLastRun()
+	if err != nil {
+		l.ErrorF("Unable to read when decay last ran: %s", err.Error())
+		return
+	}
+	if !lastRun.Before(due) {
+		return
+	}
+	count, err := applyDecayAdjustments("schedule", lastRun)
+	if err == errDecayRan { // someone applied it by hand while we waited
+		return
+	}
+	if err != nil {
+		l.ErrorF("Unable to apply scheduled decay: %s", err.Error())
+		return
+	}
+	l.InfoF("Scheduled decay applied to %d players", count)
+	if configuration.DecayChannelID != "" {
+		if _, err := s.ChannelMessageSend(configuration.DecayChannelID, fmt.Sprintf("Scheduled decay (%s) applied to %d players", decayRules(), count)); err != nil {
+			l.ErrorF("Error announcing decay: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecayAdjustments(t *testing.T) {
+	sheets := map[string]string{
+		sheetRoster: "Name,Level,Class,Rank\n" +
+			"Alpha,60,Wizard,Member\n" +
+			"Bravo,60,Cleric,Member\n" +
+			"Charlie,60,Warrior,Member\n" +
+			"Delta,60,Rogue,Member\n",
+		sheetDKP: "Name,Last Raid,Attendance,DKP\n" +
+			"Alpha,01/02/2021,100%,\"1,000\"\n" +
+			"Bravo,01/02/2021,100%,120\n" +
+			"Charlie,01/02/2021,100%,105\n" +
+			"Delta,01/02/2021,100%,50\n",
+	}
+	type adjustment struct {
+		name          string
+		decay, capped int
+	}
+	tests := []struct {
+		name    string
+		percent int
+		floor   int
+		cap     int
+		want    []adjustment
+	}{
+		{
+			name: "nothing configured",
+		},
+		{
+			name:    "decay only",
+			percent: 10,
+			want:    []adjustment{{"Alpha", 100, 0}, {"Bravo", 12, 0}, {"Charlie", 10, 0}, {"Delta", 5, 0}},
+		},
+		{
+			name:    "decay stops at the floor",
+			percent: 10,
+			floor:   100,
+			want:    []adjustment{{"Alpha", 100, 0}, {"Bravo", 12, 0}, {"Charlie", 5, 0}},
+		},
+		{
+			name: "cap only",
+			cap:  110,
+			want: []adjustment{{"Alpha", 0, 890}, {"Bravo", 0, 10}},
+		},
+		{
+			name:    "cap applies after decay",
+			percent: 10,
+			cap:     500,
+			want:    []adjustment{{"Alpha", 100, 400}, {"Bravo", 12, 0}, {"Charlie", 10, 0}, {"Delta", 5, 0}},
+		},
+	}
+	useCSVSheets(t, sheets)
+	players, err := fetchAllPlayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		configuration.DecayPercent, configuration.DecayFloor, configuration.DKPCap = 0, 0, 0
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configuration.DecayPercent, configuration.DecayFloor, configuration.DKPCap = tt.percent, tt.floor, tt.cap
+			var got []adjustment
+			for _, adj := range decayAdjustments(players) {
+				got = append(got, adjustment{adj.player.name, adj.decay, adj.capped})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "strings"
+
+// eqClasses are the class names in EQEmu bitmask order (1 << index)
+var eqClasses = []string{"warrior", "cleric", "paladin", "ranger", "shadow knight", "druid", "monk", "bard", "rogue", "shaman", "necromancer", "wizard", "magician", "enchanter", "beastlord", "berserker"}
+
+// eqRaces are the playable race names in EQEmu bitmask order (1 << index)
+var eqRaces = []string{"Human", "Barbarian", "Erudite", "Wood Elf", "High Elf", "Dark Elf", "Half Elf", "Dwarf", "Troll", "Ogre", "Halfling", "Gnome", "Iksar", "Vah Shir", "Froglok", "Drakkin"}
+
+// eqSlots are the equipment slot names in EQEmu bitmask order (1 << index)
+var eqSlots = []string{"Charm", "Ear", "Head", "Face", "Ear", "Neck", "Shoulders", "Arms", "Back", "Wrist", "Wrist", "Range", "Hands", "Primary", "Secondary", "Finger", "Finger", "Chest", "Legs", "Feet", "Waist", "Power Source", "Ammo"}
+
+// decodeBitmask returns the names whose bit is set in mask, skipping duplicates.
+// When every name is set it returns all instead
+func decodeBitmask(mask int, names []string, all string) []string {
+	var decoded []string
+	seen := make(map[string]bool)
+	count := 0
+	for i, name := range names {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		count++
+		if !seen[name] {
+			seen[name] = true
+			decoded = append(decoded, name)
+		}
+	}
+	if count == len(names) && all != "" {
+		return []string{all}
+	}
+	return decoded
+}
+
+// titleList capitalizes and joins names for display
+func titleList(names []string) string {
+	if len(names) == 0 {
+		return "None"
+	}
+	return strings.Title(strings.Join(names, ", "))
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sparkBlocks draw a sparkline from lowest to highest
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Size of the !history chart image
+const (
+	chartWidth   = 480
+	chartHeight  = 160
+	chartPadding = 8
+)
+
+// historyChartArg asks !history for a chart image
+const historyChartArg = "chart"
+
+// HistoryRow is one of a player's rows on the summary sheet
+type HistoryRow struct {
+	date        string
+	description string
+	dkp         int
+	balance     int // running balance after this row
+}
+
+// LookupHistory lists a player's summary sheet rows with a running balance, totals per
+// raid and items won, optionally over the last few days and with a chart
+func LookupHistory(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response BotResponse) {
+	l := LogInit("LookupHistory-history.go")
+	defer l.End()
+	var arg string
+	var days int
+	var chart bool
+	for _, token := range message[1:] {
+		switch n, err := strconv.Atoi(token); {
+		case err == nil && n > 0:
+			days = n
+		case strings.ToLower(token) == historyChartArg:
+			chart = true
+		default:
+			arg = token
+		}
+	}
+	name, err := resolveCharacter(m, arg)
+	if err != nil {
+		return textResponse(err.Error())
+	}
+	history, err := playerHistory(name)
+	if err != nil {
+		l.ErrorF("Unable to read history for %s: %s", name, err.Error())
+		return textResponse(err.Error())
+	}
+	if days > 0 {
+		history = historySince(history, time.Now().AddDate(0, 0, -days))
+	}
+	if len(history) == 0 {
+		return textResponse(fmt.Sprintf("%s has no DKP history", name))
+	}
+	text := fmt.Sprintf("%s\n", name)
+	var raids []string
+	raidTotals := make(map[string]int)
+	var items []string
+	var decayed int
+	for _, row := range history {
+		text = fmt.Sprintf("%s%s\t%s\t%+d\t%d\n", text, row.date, row.description, row.dkp, row.balance)
+		if isDecayRow(row.description) { // not a raid or an item
+			decayed += row.dkp
+			continue
+		}
+		if _, ok := raidTotals[row.date]; !ok {
+			raids = append(raids, row.date)
+		}
+		raidTotals[row.date] += row.dkp
+		if row.dkp < 0 {
+			items = append(items, fmt.Sprintf("%s (%d)", row.description, -row.dkp))
+		}
+	}
+	text += "\nPer raid:\n"
+	for _, raid := range raids {
+		text = fmt.Sprintf("%s%s:\t%+d\n", text, raid, raidTotals[raid])
+	}
+	if len(items) > 0 {
+		text = fmt.Sprintf("%s\nItems won: %s\n", text, strings.Join(items, ", "))
+	}
+	if decayed != 0 {
+		text = fmt.Sprintf("%s\nDecay and cap: %+d\n", text, decayed)
+	}
+	text = fmt.Sprintf("%s\nBalance: %s %d\n", text, sparkline(history), history[len(history)-1].balance)
+	response = textResponse(text)
+	if chart {
+		img, err := balanceChart(history)
+		if err != nil {
+			l.ErrorF("Unable to draw history chart for %s: %s", name, err.Error())
+			return response
+		}
+		response.files = append(response.files, ResponseFile{name: name + "-history.png", contentType: "image/png", data: img.Bytes()})
+	}
+	return response
+}
+
+// playerHistory reads every summary sheet row for a player in sheet order
+func playerHistory(name string) ([]HistoryRow, error) {
+	rows, err := dataSource.Summary()
+	if err != nil {
+		return nil, err
+	}
+	summary, err := mapColumns(sheetSummary, rows)
+	if err != nil {
+		return nil, err
+	}
+	var history []HistoryRow
+	var balance int
+	for _, row := range summary.dataRows(rows) {
+		if !strings.EqualFold(strings.TrimSpace(summary.cell(row, colPlayer)), name) {
+			continue
+		}
+		dkp, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(summary.cell(row, colDKP)), ",", ""))
+		if err != nil {
+			continue // not a DKP row
+		}
+		balance += dkp
+		history = append(history, HistoryRow{date: summary.cell(row, colDate), description: summary.cell(row, colDesc), dkp: dkp, balance: balance})
+	}
+	return history, nil
+}
+
+// historySince keeps rows dated after since, rows without a date we understand are dropped
+func historySince(history []HistoryRow, since time.Time) []HistoryRow {
+	var kept []HistoryRow
+	for _, row := range history {
+		if date, ok := parseSheetDate(row.date); ok && !date.Before(since) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// balanceRange returns the lowest and highest balance
+func balanceRange(history []HistoryRow) (int, int) {
+	low, high := history[0].balance, history[0].balance
+	for _, row := range history {
+		if row.balance < low {
+			low = row.balance
+		}
+		if row.balance > high {
+			high = row.balance
+		}
+	}
+	return low, high
+}
+
+// sparkline draws the running balance as block characters
+func sparkline(history []HistoryRow) string {
+	low, high := balanceRange(history)
+	var line []rune
+	for _, row := range history {
+		i := 0
+		if high > low {
+			i = (row.balance - low) * (len(sparkBlocks) - 1) / (high - low)
+		}
+		line = append(line, sparkBlocks[i])
+	}
+	return string(line)
+}
+
+// balanceChart draws the running balance as a PNG line chart
+func balanceChart(history []HistoryRow) (*bytes.Buffer, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	background := color.RGBA{0x36, 0x39, 0x3f, 0xff} // Discord's dark theme
+	line := color.RGBA{0x43, 0xb5, 0x81, 0xff}
+	axis := color.RGBA{0x72, 0x76, 0x7d, 0xff}
+	for x := 0; x < chartWidth; x++ {
+		for y := 0; y < chartHeight; y++ {
+			img.Set(x, y, background)
+		}
+	}
+	low, high := balanceRange(history)
+	if low > 0 {
+		low = 0
+	}
+	if high == low {
+		high = low + 1
+	}
+	point := func(i int) (int, int) {
+		x := chartPadding
+		if len(history) > 1 {
+			x += i * (chartWidth - 2*chartPadding) / (len(history) - 1)
+		}
+		y := chartHeight - chartPadding - (history[i].balance-low)*(chartHeight-2*chartPadding)/(high-low)
+		return x, y
+	}
+	zero := chartHeight - chartPadding + low*(chartHeight-2*chartPadding)/(high-low)
+	drawLine(img, chartPadding, zero, chartWidth-chartPadding, zero, axis)
+	for i := 1; i < len(history); i++ {
+		x0, y0 := point(i - 1)
+		x1, y1 := point(i)
+		drawLine(img, x0, y0, x1, y1, line)
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// drawLine draws a one pixel line with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// itemColumns is the column list scanned by scanItem
+const itemColumns = `i.id,i.Name,i.ac,i.hp,i.mana,i.astr,i.asta,i.aagi,i.adex,i.awis,i.aint,i.acha,i.mr,i.cr,i.dr,i.fr,i.pr,i.svcorruption,
+	i.slots,i.classes,i.races,i.weight,i.damage,i.delay,i.magic,i.nodrop,i.loregroup,
+	COALESCE(fs.name,''),COALESCE(cs.name,''),COALESCE(ws.name,''),COALESCE(ps.name,'')`
+
+// itemJoins resolves the item's focus, click, worn and proc effects to spell names
+const itemJoins = `LEFT JOIN spells_new fs ON fs.id = i.focuseffect
+	LEFT JOIN spells_new cs ON cs.id = i.clickeffect
+	LEFT JOIN spells_new ws ON ws.id = i.worneffect
+	LEFT JOIN spells_new ps ON ps.id = i.proceffect`
+
+// Item is an entry from the EQEmu items table
+type Item struct {
+	id          int
+	name        string
+	ac          int
+	hp          int
+	mana        int
+	str         int
+	sta         int
+	agi         int
+	dex         int
+	wis         int
+	intel       int
+	cha         int
+	mr          int
+	cr          int
+	dr          int
+	fr          int
+	pr          int
+	corrup      int
+	slots       int
+	classes     int
+	races       int
+	weight      int // in tenths of a stone
+	damage      int
+	delay       int
+	magic       int
+	nodrop      int // 0 means the item is no drop
+	loregroup   int // 0 means the item is not lore
+	focusEffect string
+	clickEffect string
+	wornEffect  string
+	procEffect  string
+}
+
+// LookupItem shows an item's stats from the EQEmu database
+func LookupItem(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("LookupItem-items.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Item command ran without an item: %s", message)
+		return ""
+	}
+	db, err := getDB()
+	if err != nil {
+		return err.Error()
+	}
+	item, choices, err := resolveItem(strings.Join(message[1:], " "), db)
+	if err != nil {
+		return err.Error()
+	}
+	if choices != "" {
+		return choices
+	}
+	return item.card()
+}
+
+// resolveItem narrows a name (or id) search down to a single item, when the name
+// is ambiguous it returns a list of candidates for the user to choose from instead
+func resolveItem(name string, db *sql.DB) (Item, string, error) {
+	l := LogInit("resolveItem-items.go")
+	defer l.End()
+	name = strings.TrimSpace(name)
+	if id, err := strconv.Atoi(name); err == nil {
+		item, err := scanItem(db.QueryRow("SELECT "+itemColumns+" FROM items i "+itemJoins+" WHERE i.id = ?", id))
+		if err == sql.ErrNoRows {
+			return Item{}, "", fmt.Errorf("No item found with id %d", id)
+		}
+		if err != nil {
+			l.ErrorF("Error looking up item %d: %s", id, err.Error())
+			return Item{}, "", gameDBFailed(err)
+		}
+		return item, "", nil
+	}
+	items, err := getItemsByName(name, db)
+	if err != nil {
+		l.ErrorF("Error looking up item %s: %s", name, err.Error())
+		return Item{}, "", gameDBFailed(err)
+	}
+	if len(items) == 0 {
+		return Item{}, "", fmt.Errorf("No item found matching %s", name)
+	}
+	if len(items) == 1 {
+		return items[0], "", nil
+	}
+	var exact []Item
+	for _, item := range items {
+		if strings.EqualFold(item.name, name) {
+			exact = append(exact, item)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0], "", nil
+	}
+	l.InfoF("%d items match %s, asking user to choose", len(items), name)
+	var names []string
+	for _, item := range items {
+		names = append(names, fmt.Sprintf("%s (id %d)", item.name, item.id))
+	}
+	return Item{}, listMatches("items", name, names), nil
+}
+
+func getItemsByName(name string, db *sql.DB) ([]Item, error) {
+	var items []Item
+	stmtOut, err := db.Prepare("SELECT " + itemColumns + " FROM items i " + itemJoins + " WHERE i.Name LIKE ? ESCAPE '\\\\' ORDER BY i.Name = ? DESC, i.Name, i.id LIMIT ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmtOut.Close()
+	rows, err := stmtOut.Query(likeContains(name), name, maxSearchResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func scanItem(row rowScanner) (Item, error) {
+	var item Item
+	err := row.Scan(&item.id, &item.name, &item.ac, &item.hp, &item.mana, &item.str, &item.sta, &item.agi, &item.dex, &item.wis, &item.intel, &item.cha,
+		&item.mr, &item.cr, &item.dr, &item.fr, &item.pr, &item.corrup, &item.slots, &item.classes, &item.races, &item.weight, &item.damage, &item.delay,
+		&item.magic, &item.nodrop, &item.loregroup, &item.focusEffect, &item.clickEffect, &item.wornEffect, &item.procEffect)
+	if err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+func (item Item) card() string {
+	var flags []string
+	if item.magic > 0 {
+		flags = append(flags, "MAGIC ITEM")
+	}
+	if item.loregroup != 0 {
+		flags = append(flags, "LORE ITEM")
+	}
+	if item.nodrop == 0 {
+		flags = append(flags, "NO DROP")
+	}
+	response := fmt.Sprintf("%s (id %d)\n", item.name, item.id)
+	if len(flags) > 0 {
+		response = fmt.Sprintf("%s%s\n", response, strings.Join(flags, "  "))
+	}
+	response = fmt.Sprintf("%sSlot: %s\n", response, strings.ToUpper(strings.Join(decodeBitmask(item.slots, eqSlots, ""), " ")))
+	if item.damage > 0 {
+		response = fmt.Sprintf("%sDMG: %d\tDelay: %d\n", response, item.damage, item.delay)
+	}
+	response = fmt.Sprintf("%sAC: %d\tHP: %d\tMana: %d\n", response, item.ac, item.hp, item.mana)
+	stats := statList([]string{"STR", "STA", "AGI", "DEX", "WIS", "INT", "CHA"}, []int{item.str, item.sta, item.agi, item.dex, item.wis, item.intel, item.cha})
+	if stats != "" {
+		response = fmt.Sprintf("%s%s\n", response, stats)
+	}
+	resists := statList([]string{"SV MAGIC", "SV COLD", "SV DISEASE", "SV FIRE", "SV POISON", "SV CORRUPT"}, []int{item.mr, item.cr, item.dr, item.fr, item.pr, item.corrup})
+	if resists != "" {
+		response = fmt.Sprintf("%s%s\n", response, resists)
+	}
+	response = fmt.Sprintf("%sWT: %.1f\n", response, float64(item.weight)/10)
+	response = fmt.Sprintf("%sClass: %s\n", response, titleList(decodeBitmask(item.classes, eqClasses, "all")))
+	response = fmt.Sprintf("%sRace: %s\n", response, titleList(decodeBitmask(item.races, eqRaces, "all")))
+	effects := []struct {
+		kind  string
+		spell string
+	}{{"Focus", item.focusEffect}, {"Click", item.clickEffect}, {"Worn", item.wornEffect}, {"Proc", item.procEffect}}
+	for _, effect := range effects {
+		if effect.spell != "" {
+			response = fmt.Sprintf("%s%s Effect: %s\n", response, effect.kind, effect.spell)
+		}
+	}
+	return response
+}
+
+// statList formats the non-zero stats as NAME: +value pairs
+func statList(names []string, values []int) string {
+	var stats []string
+	for i, value := range values {
+		if value != 0 {
+			stats = append(stats, fmt.Sprintf("%s: %+d", names[i], value))
+		}
+	}
+	return strings.Join(stats, "\t")
+}
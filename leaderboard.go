@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultLeaderboardSize is how many raiders !top shows when it isn't given a number
+const defaultLeaderboardSize = 10
+
+// leaderboardPageSize is how many raiders are on each page of !top
+const leaderboardPageSize = 10
+
+// Leaderboard sort orders
+const (
+	sortDKP        = "dkp"
+	sortAttendance = "attendance"
+	sortLastRaid   = "lastraid"
+)
+
+// leaderboardSorts are the words !top understands as a sort order
+var leaderboardSorts = map[string]string{
+	"dkp":        sortDKP,
+	"attendance": sortAttendance,
+	"att":        sortAttendance,
+	"ra":         sortAttendance,
+	"lastraid":   sortLastRaid,
+	"raid":       sortLastRaid,
+	"recent":     sortLastRaid,
+}
+
+// LeaderboardFilter is what !top was asked for
+type LeaderboardFilter struct {
+	size          int
+	classes       []string
+	ranks         []string
+	minAttendance int
+	sort          string
+}
+
+// LookupLeaderboard lists the top DKP holders, optionally filtered by class or group, rank
+// and attendance, sorted by DKP, attendance or last raid. Long lists are paged
+func LookupLeaderboard(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response BotResponse) {
+	l := LogInit("LookupLeaderboard-leaderboard.go")
+	defer l.End()
+	players := lookupAllPlayer()
+	filter, err := parseLeaderboardFilter(message[1:], players)
+	if err != nil {
+		return textResponse(err.Error())
+	}
+	var attendance RaidAttendance
+	if filter.minAttendance > 0 || filter.sort == sortAttendance {
+		if attendance, err = readRaidAttendance(); err != nil {
+			l.ErrorF("Unable to read attendance for the leaderboard: %s", err.Error())
+			return textResponse(err.Error())
+		}
+	}
+	var groups []PlayerGroup
+	for _, group := range groupByMain(players) {
+		if filter.matches(group.main, attendance) {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 {
+		return textResponse("Nobody matches that")
+	}
+	filter.sortGroups(groups, attendance)
+	if len(groups) > filter.size {
+		groups = groups[:filter.size]
+	}
+	title := fmt.Sprintf("Top %d by %s", len(groups), filter.sort)
+	var pages []BotResponse
+	for start := 0; start < len(groups); start += leaderboardPageSize {
+		end := start + leaderboardPageSize
+		if end > len(groups) {
+			end = len(groups)
+		}
+		page := BotResponse{text: title + "\n"}
+		var mains []Player
+		var fields []*discordgo.MessageEmbedField
+		for i, group := range groups[start:end] {
+			page.text += group.format()
+			field := group.field()
+			field.Name = fmt.Sprintf("%d. %s", start+i+1, field.Name)
+			fields = append(fields, field)
+			mains = append(mains, group.main)
+		}
+		page.text += rosterAge() + "\n"
+		page.embeds = []*discordgo.MessageEmbed{{Title: title, Color: classColor(mains...), Fields: fields, Footer: dataFooter()}}
+		pages = append(pages, page)
+	}
+	if len(pages) == 1 {
+		pages[0].text = strings.TrimSuffix(pages[0].text, "\n")
+		return pages[0]
+	}
+	if err := sendPages(s, m.ChannelID, pages); err != nil {
+		l.ErrorF("Unable to send leaderboard pages: %s", err.Error())
+		return textResponse("Unable to send the leaderboard")
+	}
+	return BotResponse{}
+}
+
+// parseLeaderboardFilter reads [n] [class|group] [rank] [min-attendance] [dkp|attendance|lastraid].
+// Words can come in any order, the first bare number is n and the second is the minimum
+// attendance, which can also be given anywhere as a percentage like 50%
+func parseLeaderboardFilter(args []string, players []Player) (LeaderboardFilter, error) {
+	filter := LeaderboardFilter{size: defaultLeaderboardSize, sort: sortDKP}
+	numbers := 0
+	ranks := make(map[string]string)
+	for _, player := range players {
+		ranks[strings.ToLower(player.rank)] = player.rank
+	}
+	groups, aliases := classGroups(), classAliases()
+	for i := 0; i < len(args); i++ {
+		token := strings.ToLower(args[i])
+		if i+1 < len(args) { // two word classes like shadow knight
+			if classes := expandClassGroup(token+" "+strings.ToLower(args[i+1]), groups, aliases, make(map[string]bool)); len(classes) > 0 {
+				filter.classes = append(filter.classes, classes...)
+				i++
+				continue
+			}
+		}
+		if strings.HasSuffix(token, "%") {
+			if n, err := strconv.Atoi(strings.TrimSuffix(token, "%")); err == nil {
+				filter.minAttendance = n
+				continue
+			}
+		}
+		if n, err := strconv.Atoi(token); err == nil && n >= 0 {
+			numbers++
+			switch numbers {
+			case 1:
+				if n == 0 {
+					return filter, fmt.Errorf("%s isn't a number of raiders", args[i])
+				}
+				filter.size = n
+			case 2:
+				filter.minAttendance = n
+			default:
+				return filter, fmt.Errorf("%s is one number too many, use [n] [class] [rank] [min-attendance]", args[i])
+			}
+			continue
+		}
+		if order, ok := leaderboardSorts[token]; ok {
+			filter.sort = order
+			continue
+		}
+		if classes := expandClassGroup(token, groups, aliases, make(map[string]bool)); len(classes) > 0 {
+			filter.classes = append(filter.classes, classes...)
+			continue
+		}
+		if rank, ok := ranks[token]; ok {
+			filter.ranks = append(filter.ranks, rank)
+			continue
+		}
+		return filter, fmt.Errorf("%s isn't a class, group, rank, attendance%% or sort (dkp, attendance, lastraid)", args[i])
+	}
+	return filter, nil
+}
+
+// matches returns true if the player passes every filter
+func (lf LeaderboardFilter) matches(player Player, attendance RaidAttendance) bool {
+	if len(lf.classes) > 0 && !containsString(lf.classes, strings.TrimSpace(strings.ToLower(player.class))) {
+		return false
+	}
+	if len(lf.ranks) > 0 && !containsString(lf.ranks, player.rank) {
+		return false
+	}
+	return lf.minAttendance <= 0 || attendance.percent(player.name) >= lf.minAttendance
+}
+
+// sortGroups puts the best first by the filter's sort order, ties go to DKP
+func (lf LeaderboardFilter) sortGroups(groups []PlayerGroup, attendance RaidAttendance) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		switch lf.sort {
+		case sortAttendance:
+			if pa, pb := attendance.percent(a.main.name), attendance.percent(b.main.name); pa != pb {
+				return pa > pb
+			}
+		case sortLastRaid:
+			ra, _ := parseSheetDate(a.main.lastRaid)
+			rb, _ := parseSheetDate(b.main.lastRaid)
+			if !ra.Equal(rb) {
+				return ra.After(rb)
+			}
+		}
+		return a.dkp() > b.dkp()
+	})
+}
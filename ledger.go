@@ -0,0 +1,396 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// bucketLedger holds every DKP transaction keyed by id
+const bucketLedger = "ledger"
+
+// Kinds of ledger entries
+const (
+	ledgerTick   = "tick"   // earned for being at a raid
+	ledgerSpend  = "spend"  // paid for an item
+	ledgerAdjust = "adjust" // officer correction, can go either way
+	ledgerDecay  = "decay"  // taken by !decay, recorded here so balances can be replayed
+)
+
+// defaultLedgerExportSheet is used when LedgerExportSheetName isn't configured
+const defaultLedgerExportSheet = "Ledger"
+
+// defaultLedgerAttendanceDays is used when LedgerAttendanceDays isn't configured
+const defaultLedgerAttendanceDays = 30
+
+// ledgerHistoryLength is how many entries !ledger balance shows
+const ledgerHistoryLength = 10
+
+// LedgerEntry is one DKP transaction, balances are the sum of a character's entries
+type LedgerEntry struct {
+	ID          int       `json:"ID"`
+	Time        time.Time `json:"Time"`
+	Kind        string    `json:"Kind"`
+	Character   string    `json:"Character"`
+	Amount      int       `json:"Amount"`          // signed change to the balance
+	Description string    `json:"Description"`     // item, raid or reason
+	Event       int       `json:"Event,omitempty"` // ticks recorded together share an event, used for attendance
+	Percent     int       `json:"Percent,omitempty"`
+	RecordedBy  string    `json:"RecordedBy"`
+}
+
+// ledgerAccount is a character's standing rebuilt from the ledger
+type ledgerAccount struct {
+	name     string
+	dkp      int
+	lastRaid time.Time
+	events   map[int]time.Time // tick events attended
+}
+
+// Ledger records DKP transactions, see ledgerUsage for the subcommands
+func Ledger(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("Ledger-ledger.go")
+	defer l.End()
+	if len(message) < 2 {
+		return ledgerUsage()
+	}
+	args := message[2:]
+	subcommand := strings.ToLower(message[1])
+	if subcommand != "balance" && !isPriviledged(s, m.Author.ID) { // anyone can check a balance, only officers record
+		l.WarnF("%s tried to %s the ledger without being priviledged", m.Author.Username, subcommand)
+		return configuration.NoPrivResponse
+	}
+	switch subcommand {
+	case ledgerTick:
+		return ledgerRecordTick(m, args)
+	case ledgerSpend:
+		return ledgerRecordSpend(m, args)
+	case ledgerAdjust:
+		return ledgerRecordAdjust(m, args)
+	case "balance":
+		return ledgerBalance(args)
+	case "export":
+		return ledgerExport()
+	}
+	return ledgerUsage()
+}
+
+func ledgerUsage() string {
+	cmd := configuration.CommLedgerCommand
+	return fmt.Sprintf("%s tick <dkp> <character,character,...> [raid]\n%s spend <character> <dkp> <item>\n%s adjust <character> <+/-dkp> <reason>\n%s balance [character]\n%s export\nDecay is recorded by %s apply\n", cmd, cmd, cmd, cmd, cmd, configuration.CommDecayCommand)
+}
+
+func ledgerRecordTick(m *discordgo.MessageCreate, args []string) string {
+	l := LogInit("ledgerRecordTick-ledger.go")
+	defer l.End()
+	if len(args) < 2 {
+		return ledgerUsage()
+	}
+	amount, err := strconv.Atoi(args[0])
+	if err != nil || amount <= 0 {
+		return fmt.Sprintf("%s is not a DKP amount", args[0])
+	}
+	var characters []string
+	for _, name := range strings.Split(args[1], ",") {
+		if name = normalizeCharacter(name); name != "" && !containsString(characters, name) {
+			characters = append(characters, name)
+		}
+	}
+	if unknown := unknownCharacters(characters); len(unknown) > 0 {
+		return fmt.Sprintf("Not on the roster: %s", strings.Join(unknown, ", "))
+	}
+	description := strings.Join(args[2:], " ")
+	if description == "" {
+		description = "Attendance"
+	}
+	now := time.Now()
+	err = store.Update(func(tx *StoreTx) error {
+		var event int
+		for _, character := range characters {
+			entry := LedgerEntry{ID: tx.NextID(bucketLedger), Time: now, Kind: ledgerTick, Character: character, Amount: amount, Description: description, Event: event, RecordedBy: m.Author.Username}
+			if event == 0 {
+				event = entry.ID
+				entry.Event = event
+			}
+			if err := tx.Put(bucketLedger, strconv.Itoa(entry.ID), entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		l.ErrorF("Error recording tick: %s", err.Error())
+		return "Unable to record the tick, please try again"
+	}
+	l.InfoF("%s ticked %d for %d characters: %s", m.Author.Username, amount, len(characters), description)
+	return fmt.Sprintf("%d DKP for %s recorded for %d characters", amount, description, len(characters))
+}
+
+func ledgerRecordSpend(m *discordgo.MessageCreate, args []string) string {
+	if len(args) < 3 {
+		return ledgerUsage()
+	}
+	amount, err := strconv.Atoi(args[1])
+	if err != nil || amount <= 0 {
+		return fmt.Sprintf("%s is not a DKP amount", args[1])
+	}
+	return recordLedgerEntry(m, LedgerEntry{Kind: ledgerSpend, Character: normalizeCharacter(args[0]), Amount: -amount, Description: strings.Join(args[2:], " ")})
+}
+
+func ledgerRecordAdjust(m *discordgo.MessageCreate, args []string) string {
+	if len(args) < 3 {
+		return ledgerUsage()
+	}
+	amount, err := strconv.Atoi(args[1])
+	if err != nil || amount == 0 {
+		return fmt.Sprintf("%s is not a DKP amount", args[1])
+	}
+	return recordLedgerEntry(m, LedgerEntry{Kind: ledgerAdjust, Character: normalizeCharacter(args[0]), Amount: amount, Description: strings.Join(args[2:], " ")})
+}
+
+// recordLedgerEntry saves a single spend or adjustment
+func recordLedgerEntry(m *discordgo.MessageCreate, entry LedgerEntry) string {
+	l := LogInit("recordLedgerEntry-ledger.go")
+	defer l.End()
+	if unknown := unknownCharacters([]string{entry.Character}); len(unknown) > 0 {
+		return fmt.Sprintf("%s is not on the roster", entry.Character)
+	}
+	entry.Time = time.Now()
+	entry.RecordedBy = m.Author.Username
+	var balance int
+	err := store.Update(func(tx *StoreTx) error {
+		if err := putLedgerEntry(tx, &entry); err != nil {
+			return err
+		}
+		accounts, err := ledgerAccounts(tx)
+		if err != nil {
+			return err
+		}
+		balance = accounts[entry.Character].dkp
+		return nil
+	})
+	if err != nil {
+		l.ErrorF("Error recording %s for %s: %s", entry.Kind, entry.Character, err.Error())
+		return "Unable to record that, please try again"
+	}
+	l.InfoF("%s recorded %s %d for %s: %s", m.Author.Username, entry.Kind, entry.Amount, entry.Character, entry.Description)
+	return fmt.Sprintf("%s %+d for %s, balance is now %d", entry.Character, entry.Amount, entry.Description, balance)
+}
+
+// putLedgerEntry gives entry the next id and saves it
+func putLedgerEntry(tx *StoreTx, entry *LedgerEntry) error {
+	entry.ID = tx.NextID(bucketLedger)
+	return tx.Put(bucketLedger, strconv.Itoa(entry.ID), entry)
+}
+
+func ledgerBalance(args []string) (response string) {
+	l := LogInit("ledgerBalance-ledger.go")
+	defer l.End()
+	var accounts map[string]*ledgerAccount
+	var history []LedgerEntry
+	character := ""
+	if len(args) > 0 {
+		character = normalizeCharacter(args[0])
+	}
+	err := store.View(func(tx *StoreTx) error {
+		var err error
+		if accounts, err = ledgerAccounts(tx); err != nil {
+			return err
+		}
+		if character == "" {
+			return nil
+		}
+		return forEachLedgerEntry(tx, func(entry LedgerEntry) error {
+			if entry.Character == character {
+				history = append(history, entry)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		l.ErrorF("Error reading the ledger: %s", err.Error())
+		return "Unable to read the ledger at this time"
+	}
+	if character == "" {
+		list := sortedAccounts(accounts)
+		if len(list) == 0 {
+			return "The ledger is empty"
+		}
+		for _, account := range list {
+			response = fmt.Sprintf("%s%s:\t%d\n", response, account.name, account.dkp)
+		}
+		return response
+	}
+	account, ok := accounts[character]
+	if !ok {
+		return fmt.Sprintf("%s has no ledger entries", character)
+	}
+	response = fmt.Sprintf("%s:\t%d\n", account.name, account.dkp)
+	if len(history) > ledgerHistoryLength {
+		history = history[len(history)-ledgerHistoryLength:]
+	}
+	for _, entry := range history {
+		response = fmt.Sprintf("%s%s\t%s\t%+d\t%s\n", response, entry.Time.Format(sheetDateLayouts[0]), entry.Kind, entry.Amount, entry.Description)
+	}
+	return response
+}
+
+// ledgerExport writes balances to LedgerExportSheetName in the layout of the DKP sheet,
+// point DKPSheetName at it to make the ledger the source of truth
+func ledgerExport() string {
+	l := LogInit("ledgerExport-ledger.go")
+	defer l.End()
+	rows, err := ledgerSheetRows()
+	if err != nil {
+		l.ErrorF("Error reading the ledger: %s", err.Error())
+		return "Unable to read the ledger at this time"
+	}
+	if len(rows) < 2 {
+		return "The ledger is empty"
+	}
+	sheet := configuration.LedgerExportSheetName
+	if sheet == "" {
+		sheet = defaultLedgerExportSheet
+	}
+	if err := dataSource.WriteSheet(sheet, rows); err != nil {
+		l.ErrorF("Error exporting the ledger to %s: %s", sheet, err.Error())
+		return fmt.Sprintf("Unable to write the %s sheet", sheet)
+	}
+	l.InfoF("Exported %d ledger balances to %s", len(rows)-1, sheet)
+	return fmt.Sprintf("Exported %d balances to %s", len(rows)-1, sheet)
+}
+
+// ledgerSheetRows builds the DKP sheet, header first, from the ledger
+func ledgerSheetRows() ([][]interface{}, error) {
+	var accounts map[string]*ledgerAccount
+	err := store.View(func(tx *StoreTx) error {
+		var err error
+		accounts, err = ledgerAccounts(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := sortedAccounts(accounts)
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	events := ledgerEvents(accounts, ledgerAttendanceSince())
+	classes := make(map[string]string) // looked up once, not per account
+	for _, player := range lookupAllPlayer() {
+		classes[strings.TrimSpace(player.name)] = player.class
+	}
+	rows := [][]interface{}{{"Name", "Class", "Last Raid", "Attendance", "DKP"}}
+	for _, account := range list {
+		var lastRaid string
+		if !account.lastRaid.IsZero() {
+			lastRaid = account.lastRaid.Format(sheetDateLayouts[0])
+		}
+		rows = append(rows, []interface{}{account.name, accountClass(classes, account.name), lastRaid, fmt.Sprintf("%d%%", account.attendance(events)), account.dkp})
+	}
+	return rows, nil
+}
+
+// accountClass is the roster class of a ledger account, Unknown like lookupPlayer if it isn't on the roster
+func accountClass(classes map[string]string, name string) string {
+	if class, ok := classes[normalizeCharacter(name)]; ok {
+		return class
+	}
+	return "Unknown"
+}
+
+// ledgerAccounts replays the ledger into every character's balance
+func ledgerAccounts(tx *StoreTx) (map[string]*ledgerAccount, error) {
+	accounts := make(map[string]*ledgerAccount)
+	err := forEachLedgerEntry(tx, func(entry LedgerEntry) error {
+		account, ok := accounts[entry.Character]
+		if !ok {
+			account = &ledgerAccount{name: entry.Character, events: make(map[int]time.Time)}
+			accounts[entry.Character] = account
+		}
+		account.dkp += entry.Amount
+		if entry.Kind == ledgerTick {
+			account.events[entry.Event] = entry.Time
+			if entry.Time.After(account.lastRaid) {
+				account.lastRaid = entry.Time
+			}
+		}
+		return nil
+	})
+	return accounts, err
+}
+
+func forEachLedgerEntry(tx *StoreTx, fn func(entry LedgerEntry) error) error {
+	return tx.ForEach(bucketLedger, func(key string, raw json.RawMessage) error {
+		var entry LedgerEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return err
+		}
+		return fn(entry)
+	})
+}
+
+// ledgerAttendanceSince is the start of the attendance window
+func ledgerAttendanceSince() time.Time {
+	days := configuration.LedgerAttendanceDays
+	if days <= 0 {
+		days = defaultLedgerAttendanceDays
+	}
+	return time.Now().AddDate(0, 0, -days)
+}
+
+// ledgerEvents returns every tick event since the given time
+func ledgerEvents(accounts map[string]*ledgerAccount, since time.Time) map[int]bool {
+	events := make(map[int]bool)
+	for _, account := range accounts {
+		for event, t := range account.events {
+			if t.After(since) {
+				events[event] = true
+			}
+		}
+	}
+	return events
+}
+
+// attendance is the percentage of events the character was ticked for
+func (a *ledgerAccount) attendance(events map[int]bool) int {
+	if len(events) == 0 {
+		return 0
+	}
+	var attended int
+	for event := range a.events {
+		if events[event] {
+			attended++
+		}
+	}
+	return attended * 100 / len(events)
+}
+
+// sortedAccounts returns accounts highest balance first
+func sortedAccounts(accounts map[string]*ledgerAccount) []*ledgerAccount {
+	list := make([]*ledgerAccount, 0, len(accounts))
+	for _, account := range accounts {
+		list = append(list, account)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].dkp != list[j].dkp {
+			return list[i].dkp > list[j].dkp
+		}
+		return list[i].name < list[j].name
+	})
+	return list
+}
+
+// unknownCharacters returns the characters that aren't on the roster
+func unknownCharacters(characters []string) []string {
+	var unknown []string
+	for _, character := range characters {
+		if lookupPlayer(character).name == "" {
+			unknown = append(unknown, character)
+		}
+	}
+	return unknown
+}
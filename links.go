@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// bucketSettings holds UserSettings keyed by Discord user ID
+const bucketSettings = "settings"
+
+// mentionRegex matches a Discord user mention like <@123> or <@!123>
+var mentionRegex = regexp.MustCompile(`^<@!?(\d+)>$`)
+
+// UserSettings is everything we remember about a Discord user
+type UserSettings struct {
+	UserID     string   `json:"UserID"`
+	Username   string   `json:"Username"`
+	Main       string   `json:"Main"`       // main EverQuest character
+	Characters []string `json:"Characters"` // approved characters, including the main
+	Pending    []string `json:"Pending"`    // characters waiting on officer approval
+}
+
+// normalizeCharacter formats a character name the way the roster does
+func normalizeCharacter(name string) string {
+	return strings.Title(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func getUserSettings(userID string) (UserSettings, error) {
+	settings := UserSettings{UserID: userID}
+	_, err := store.Get(bucketSettings, userID, &settings)
+	return settings, err
+}
+
+// characterOwner finds the user who has the character approved or pending
+func characterOwner(tx *StoreTx, character string) (UserSettings, bool, error) {
+	var owner UserSettings
+	found := false
+	err := tx.ForEach(bucketSettings, func(key string, raw json.RawMessage) error {
+		var settings UserSettings
+		if err := json.Unmarshal(raw, &settings); err != nil {
+			return err
+		}
+		if containsString(settings.Characters, character) || containsString(settings.Pending, character) {
+			owner = settings
+			found = true
+		}
+		return nil
+	})
+	return owner, found, err
+}
+
+// resolveCharacter turns a command argument into a character name. No argument means
+// the caller's main and a mention means the mentioned user's main
+func resolveCharacter(m *discordgo.MessageCreate, arg string) (string, error) {
+	userID := m.Author.ID
+	if arg != "" {
+		match := mentionRegex.FindStringSubmatch(arg)
+		if match == nil {
+			return normalizeCharacter(arg), nil
+		}
+		userID = match[1]
+	}
+	settings, err := getUserSettings(userID)
+	if err != nil {
+		return "", err
+	}
+	if settings.Main == "" {
+		if userID == m.Author.ID {
+			return "", fmt.Errorf("You haven't linked a character yet, use %s <character>", configuration.CommIAmCommand)
+		}
+		return "", errors.New("That user hasn't linked a character yet")
+	}
+	return settings.Main, nil
+}
+
+// LinkCharacter asks officers to link a character to the caller, or makes an
+// already linked character their main
+func LinkCharacter(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("LinkCharacter-links.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Link command ran without a character: %s", message)
+		return ""
+	}
+	character := normalizeCharacter(message[1])
+	if lookupPlayer(character).name == "" {
+		return fmt.Sprintf("%s is not on the roster", character)
+	}
+	err := store.Update(func(tx *StoreTx) error {
+		owner, found, err := characterOwner(tx, character)
+		if err != nil {
+			return err
+		}
+		if found && owner.UserID != m.Author.ID {
+			response = fmt.Sprintf("%s is already linked to someone else, ask an officer if this is wrong", character)
+			return nil
+		}
+		settings := owner
+		if !found {
+			settings = UserSettings{UserID: m.Author.ID}
+			if _, err := tx.Get(bucketSettings, m.Author.ID, &settings); err != nil {
+				return err
+			}
+		}
+		settings.Username = m.Author.Username
+		switch {
+		case containsString(settings.Characters, character):
+			settings.Main = character
+			response = fmt.Sprintf("%s is now your main", character)
+		case containsString(settings.Pending, character):
+			response = fmt.Sprintf("%s is still waiting for an officer to approve", character)
+		default:
+			settings.Pending = append(settings.Pending, character)
+			response = fmt.Sprintf("%s is waiting for an officer to approve", character)
+		}
+		return tx.Put(bucketSettings, settings.UserID, settings)
+	})
+	if err != nil {
+		l.ErrorF("Error linking %s to %s: %s", character, m.Author.Username, err.Error())
+		return "Unable to link characters at this time"
+	}
+	return response
+}
+
+// ApproveLink lets officers approve or deny a pending character link, with no
+// character it lists everything pending
+func ApproveLink(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("ApproveLink-links.go")
+	defer l.End()
+	if !isPriviledged(s, m.Author.ID) { // officers approve links however the command is configured
+		l.WarnF("%s tried to approve links without being priviledged", m.Author.Username)
+		return configuration.NoPrivResponse
+	}
+	if len(message) < 2 {
+		err := store.ForEach(bucketSettings, func(key string, raw json.RawMessage) error {
+			var settings UserSettings
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			for _, character := range settings.Pending {
+				response = fmt.Sprintf("%s%s wants %s\n", response, settings.Username, character)
+			}
+			return nil
+		})
+		if err != nil {
+			l.ErrorF("Error listing pending links: %s", err.Error())
+			return "Unable to list pending links at this time"
+		}
+		if response == "" {
+			return "No links are waiting for approval"
+		}
+		return response
+	}
+	character := normalizeCharacter(message[1])
+	deny := len(message) > 2 && strings.ToLower(message[2]) == "deny"
+	err := store.Update(func(tx *StoreTx) error {
+		owner, found, err := characterOwner(tx, character)
+		if err != nil {
+			return err
+		}
+		if !found || !containsString(owner.Pending, character) {
+			response = fmt.Sprintf("Nobody is waiting on %s", character)
+			return nil
+		}
+		owner.Pending = removeString(owner.Pending, character)
+		if deny {
+			response = fmt.Sprintf("%s will not be linked to %s", character, owner.Username)
+		} else {
+			owner.Characters = append(owner.Characters, character)
+			if owner.Main == "" {
+				owner.Main = character
+			}
+			response = fmt.Sprintf("%s is now linked to %s", character, owner.Username)
+		}
+		return tx.Put(bucketSettings, owner.UserID, owner)
+	})
+	if err != nil {
+		l.ErrorF("Error approving %s: %s", character, err.Error())
+		return "Unable to approve links at this time"
+	}
+	l.InfoF("%s by %s: %s", message, m.Author.Username, response)
+	return response
+}
+
+// ListAlts shows the characters linked to the caller, a mentioned user, or a character's owner
+func ListAlts(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("ListAlts-links.go")
+	defer l.End()
+	var settings UserSettings
+	var found bool
+	err := store.View(func(tx *StoreTx) error {
+		var err error
+		switch {
+		case len(message) < 2:
+			settings = UserSettings{UserID: m.Author.ID}
+			found, err = tx.Get(bucketSettings, m.Author.ID, &settings)
+		case mentionRegex.MatchString(message[1]):
+			userID := mentionRegex.FindStringSubmatch(message[1])[1]
+			found, err = tx.Get(bucketSettings, userID, &settings)
+		default:
+			settings, found, err = characterOwner(tx, normalizeCharacter(message[1]))
+		}
+		return err
+	})
+	if err != nil {
+		l.ErrorF("Error looking up alts: %s", err.Error())
+		return "Unable to look up characters at this time"
+	}
+	if !found || (len(settings.Characters) == 0 && len(settings.Pending) == 0) {
+		return "No characters are linked"
+	}
+	response = fmt.Sprintf("%s\nMain: %s\n", settings.Username, settings.Main)
+	if alts := removeString(settings.Characters, settings.Main); len(alts) > 0 {
+		response = fmt.Sprintf("%sAlts: %s\n", response, strings.Join(alts, ", "))
+	}
+	if len(settings.Pending) > 0 {
+		response = fmt.Sprintf("%sWaiting for approval: %s\n", response, strings.Join(settings.Pending, ", "))
+	}
+	return response
+}
+
+// removeString returns list without s
+func removeString(list []string, s string) []string {
+	var kept []string
+	for _, entry := range list {
+		if entry != s {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
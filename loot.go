@@ -0,0 +1,182 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// LootDrop is one way an item can drop from a mob's loot table
+type LootDrop struct {
+	npcID       int
+	npcName     string
+	itemID      int
+	itemName    string
+	lootdropID  int
+	multiplier  int // how many times the lootdrop is rolled
+	probability int // percent chance the lootdrop is rolled at all
+	chance      float64
+	zones       []string
+}
+
+// perKillChance is the approximate percent chance one kill drops the item at least once
+func (drop LootDrop) perKillChance() float64 {
+	roll := float64(drop.probability) / 100 * drop.chance / 100
+	rolls := drop.multiplier
+	if rolls < 1 {
+		rolls = 1
+	}
+	return (1 - math.Pow(1-roll, float64(rolls))) * 100
+}
+
+// LookupDrops lists which mobs drop an item, their chance and where they spawn
+func LookupDrops(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("LookupDrops-loot.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Drops command ran without an item: %s", message)
+		return ""
+	}
+	db, err := getDB()
+	if err != nil {
+		return err.Error()
+	}
+	item, choices, err := resolveItem(strings.Join(message[1:], " "), db)
+	if err != nil {
+		return err.Error()
+	}
+	if choices != "" {
+		return choices
+	}
+	drops, err := getDropsByItem(item.id, db)
+	if err != nil {
+		l.ErrorF("Error looking up drops for %d: %s", item.id, err.Error())
+		return gameDBFailed(err).Error()
+	}
+	if len(drops) == 0 {
+		return fmt.Sprintf("Nothing drops %s", item.name)
+	}
+	sort.SliceStable(drops, func(i, j int) bool { return drops[i].perKillChance() > drops[j].perKillChance() })
+	response = fmt.Sprintf("%s drops from:\n", item.name)
+	for _, drop := range drops {
+		zones := "no spawn points"
+		if len(drop.zones) > 0 {
+			zones = strings.Join(drop.zones, ", ")
+		}
+		response = fmt.Sprintf("%s%s (id %d):\t%.1f%%\t%s\n", response, drop.npcName, drop.npcID, drop.perKillChance(), zones)
+	}
+	return response
+}
+
+// LookupLoot lists the loot table of a mob
+func LookupLoot(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("LookupLoot-loot.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Loot command ran without a mob: %s", message)
+		return ""
+	}
+	db, err := getDB()
+	if err != nil {
+		return err.Error()
+	}
+	npc, choices, err := resolveNPC(strings.Join(message[1:], " "), db)
+	if err != nil {
+		return err.Error()
+	}
+	if choices != "" {
+		return choices
+	}
+	drops, err := getLootByNPC(npc.id, db)
+	if err != nil {
+		l.ErrorF("Error looking up loot for %d: %s", npc.id, err.Error())
+		return gameDBFailed(err).Error()
+	}
+	if len(drops) == 0 {
+		return fmt.Sprintf("%s has no loot table", npc.name)
+	}
+	response = fmt.Sprintf("%s (id %d)\n%sLoot:\n", npc.name, npc.id, npc.traitLine())
+	lastLootdrop := 0
+	for _, drop := range drops {
+		if drop.lootdropID != lastLootdrop {
+			response = fmt.Sprintf("%sLootdrop %d (%d%% x%d):\n", response, drop.lootdropID, drop.probability, drop.multiplier)
+			lastLootdrop = drop.lootdropID
+		}
+		response = fmt.Sprintf("%s\t%s (id %d):\t%.1f%%\n", response, drop.itemName, drop.itemID, drop.perKillChance())
+	}
+	return response
+}
+
+func getDropsByItem(itemID int, db *sql.DB) ([]LootDrop, error) {
+	rows, err := db.Query(`SELECT n.id, n.name, lde.item_id, lde.lootdrop_id, lte.multiplier, lte.probability, lde.chance, COALESCE(s2.zone, '')
+		FROM lootdrop_entries lde
+		JOIN loottable_entries lte ON lte.lootdrop_id = lde.lootdrop_id
+		JOIN npc_types n ON n.loottable_id = lte.loottable_id
+		LEFT JOIN spawnentry se ON se.npcID = n.id
+		LEFT JOIN spawn2 s2 ON s2.spawngroupID = se.spawngroupID
+		WHERE lde.item_id = ?
+		ORDER BY n.name, n.id`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var drops []LootDrop
+	index := make(map[string]int) // npc and lootdrop to index in drops
+	for rows.Next() {
+		var drop LootDrop
+		var zone string
+		if err := rows.Scan(&drop.npcID, &drop.npcName, &drop.itemID, &drop.lootdropID, &drop.multiplier, &drop.probability, &drop.chance, &zone); err != nil {
+			return nil, err
+		}
+		drop.npcName = cleanMobName(drop.npcName)
+		key := fmt.Sprintf("%d-%d", drop.npcID, drop.lootdropID)
+		i, ok := index[key]
+		if !ok {
+			drops = append(drops, drop)
+			i = len(drops) - 1
+			index[key] = i
+		}
+		if zone != "" && !containsString(drops[i].zones, zone) {
+			drops[i].zones = append(drops[i].zones, zone)
+		}
+	}
+	return drops, rows.Err()
+}
+
+func getLootByNPC(npcID int, db *sql.DB) ([]LootDrop, error) {
+	rows, err := db.Query(`SELECT n.id, n.name, i.id, i.Name, lde.lootdrop_id, lte.multiplier, lte.probability, lde.chance
+		FROM npc_types n
+		JOIN loottable_entries lte ON lte.loottable_id = n.loottable_id
+		JOIN lootdrop_entries lde ON lde.lootdrop_id = lte.lootdrop_id
+		JOIN items i ON i.id = lde.item_id
+		WHERE n.id = ?
+		ORDER BY lde.lootdrop_id, lde.chance DESC, i.Name`, npcID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var drops []LootDrop
+	for rows.Next() {
+		var drop LootDrop
+		if err := rows.Scan(&drop.npcID, &drop.npcName, &drop.itemID, &drop.itemName, &drop.lootdropID, &drop.multiplier, &drop.probability, &drop.chance); err != nil {
+			return nil, err
+		}
+		drop.npcName = cleanMobName(drop.npcName)
+		drops = append(drops, drop)
+	}
+	return drops, rows.Err()
+}
+
+// containsString returns true if s is in list
+func containsString(list []string, s string) bool {
+	for _, entry := range list {
+		if entry == s {
+			return true
+		}
+	}
+	return false
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -14,7 +13,6 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	_ "github.com/go-sql-driver/mysql"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
@@ -122,15 +120,9 @@ type Gtoken struct {
 	Installed Inst `json:"installed"`
 }
 
-func main() {
-	// Open Configuration and set log output
-	configFile, err := os.OpenFile(configuration.LogPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("error opening file: %v", err)
-	}
-	defer configFile.Close()
-	log.SetOutput(configFile)
-	l := LogInit("main-main.go")
+// connectGoogle authenticates with google and sets up the sheets and calendar globals
+func connectGoogle() {
+	l := LogInit("connectGoogle-main.go")
 	defer l.End()
 	gtoken := &Gtoken{
 		Installed: Inst{
@@ -170,6 +162,50 @@ func main() {
 	if err != nil {
 		l.FatalF("Unable retrieve Calendar client: %v", err)
 	}
+}
+
+func main() {
+	// Open Configuration and set log output
+	readConfig()
+	log.Printf("Configuration loaded:\n %+v\n", configuration)
+	configFile, err := os.OpenFile(configuration.LogPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening file: %v", err)
+	}
+	defer configFile.Close()
+	log.SetOutput(configFile)
+	l := LogInit("main-main.go")
+	defer l.End()
+	// Google is only needed when the sheets live there, the calendar is skipped otherwise
+	if strings.ToLower(configuration.DataSource) != dataSourceCSV {
+		connectGoogle()
+	}
+	dataSource, err = newDataSource()
+	if err != nil {
+		l.FatalF("Unable to set up data source: %v", err)
+	}
+	for _, err := range validateSheetLayouts() {
+		l.ErrorF("%s", err.Error())
+	}
+
+	// Open the datastore holding bot state
+	store, err = openStore(storePath())
+	if err != nil {
+		l.FatalF("Unable to open datastore: %v", err)
+	}
+	resumeAwards()
+
+	// Serve DKP from the last snapshot until the sheets are read again in the background
+	if err := loadRosterSnapshot(); err != nil {
+		l.ErrorF("Unable to load roster snapshot: %v", err)
+	}
+	go watchRoster()
+
+	// Connect to the EQEmu database, commands will retry if this fails
+	if _, err := getDB(); err != nil {
+		l.ErrorF("Unable to connect to the game database: %v", err)
+	}
+	defer closeDB()
 
 	// Create a new Discord session using the provided bot token. os.Getenv("DiscordToken")
 	dg, err := discordgo.New("Bot " + configuration.DiscordToken)
@@ -181,6 +217,8 @@ func main() {
 
 	// Register the messageCreate func as a callback for MessageCreate events.
 	dg.AddHandler(messageCreate)
+	// Turn the pages of long responses with reactions
+	dg.AddHandler(turnPage)
 
 	// Open a websocket connection to Discord and begin listening.
 	err = dg.Open()
@@ -189,6 +227,10 @@ func main() {
 		return
 	}
 
+	// Start announcing raid target windows, including ones from before we restarted
+	go watchRaidTimers(dg)
+	go watchDecay(dg)
+
 	// daemon.SdNotify(false, "READY=1")
 
 	// Wait here until CTRL-C or other term signal is received.
@@ -225,38 +267,7 @@ func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Split message between command and input
 	// TODO: Make this smarter and less responses sent
 	msg := strings.Split(m.Content, " ")
-	resp := runCommand(s, m, msg)
-	if resp != "" {
-		if len(resp) > configuration.MaxMessageLength { // Max Discord message is 2000 characters, we need to break it up
-			l.InfoF("Message too long, breaking it into multiple messages")
-			chunked := chunkMessages(resp)
-			for _, response := range chunked {
-				s.ChannelMessageSend(m.ChannelID, response)
-			}
-
-			// splt := strings.Split(resp, "\n")
-			// for _, line := range splt {
-			// 	s.ChannelMessageSend(m.ChannelID, line)
-			// }
-		} else {
-			s.ChannelMessageSend(m.ChannelID, resp)
-		}
-	}
-}
-
-func chunkMessages(r string) []string {
-	full := strings.Split(r, " ")
-	var response string
-	var responses []string
-	for _, word := range full {
-		if (len(response) + len(word)) < configuration.MaxMessageLength {
-			response = response + " " + word
-		} else {
-			responses = append(responses, response)
-			response = ""
-		}
-	}
-	return responses
+	sendResponse(s, m.ChannelID, runCommand(s, m, msg))
 }
 
 func getUser(s *discordgo.Session) *discordgo.User {
@@ -289,6 +300,7 @@ func isPriviledged(s *discordgo.Session, userID string) bool {
 		// 	return false, err
 		// }
 		l.ErrorF("Error: %s", err.Error())
+		return false // not a member we know, so not an officer
 	}
 	l.InfoF("Member: %+v", member)
 	for _, roleID := range member.Roles {
@@ -308,58 +320,6 @@ func isPriviledged(s *discordgo.Session, userID string) bool {
 	return false
 }
 
-func connectDB() *sql.DB {
-	db, err := sql.Open("mysql", "root:IGNOREME@/peq")
-	if err != nil {
-		panic(err)
-	}
-	// Open doesn't open a connection. Validate DSN data:
-	err = db.Ping()
-	if err != nil {
-		panic(err.Error()) // proper error handling instead of panic in your app
-	}
-	db.SetConnMaxLifetime(time.Minute * 3)
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(10)
-
-	return db
-}
-
-func getResistsByMobName(name string, db *sql.DB) (NPC, error) {
-	var npc NPC
-	name = strings.ReplaceAll(name, " ", "_")
-	// Prepare statement for reading data NAME LIKE "%Invisibility%Undead"
-	name = fmt.Sprintf("%%%s%%", name)
-	stmtOut, err := db.Prepare("SELECT id,NAME,Level,MR,CR,DR,FR,PR,Corrup,PhR,slow_mitigation,special_abilities,npcspecialattks FROM npc_types WHERE name LIKE ?")
-	if err != nil {
-		return NPC{}, err
-	}
-	defer stmtOut.Close()
-	err = stmtOut.QueryRow(name).Scan(&npc.id, &npc.oName, &npc.level, &npc.mr, &npc.cr, &npc.dr, &npc.fr, &npc.pr, &npc.corrup, &npc.phr, &npc.slowMitigation, &npc.specialAbilities, &npc.npcSpecialAttacks)
-	if err != nil {
-		return NPC{}, err
-	}
-	return npc, nil
-}
-
-// NPC is a struct for holding mob data like resists, mitigation, special flags
-type NPC struct {
-	id                int
-	name              string // is a clean name without underscores or #'s
-	oName             string // contains the unclean name
-	level             int
-	mr                int
-	cr                int
-	dr                int
-	fr                int
-	pr                int
-	corrup            int
-	phr               int
-	slowMitigation    int
-	specialAbilities  string
-	npcSpecialAttacks string
-}
-
 func getEvents(cal *calendar.Service, calID string, bDeleted bool, count int64, tFormat string) []Event {
 	t := time.Now().Format(time.RFC3339)
 	events, err := cal.Events.List(calID).ShowDeleted(false).SingleEvents(true).TimeMin(t).MaxResults(count).OrderBy("startTime").Do()
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxMatches is how many candidates we list when a name search is ambiguous
+const maxMatches = 10
+
+// maxSearchResults caps how many rows a name search reads, exact matches are read first
+const maxSearchResults = 100
+
+// npcColumns is the column list scanned by scanNPC
+const npcColumns = "id,NAME,Level,MR,CR,DR,FR,PR,Corrup,PhR,slow_mitigation,special_abilities,npcspecialattks,see_invis,see_invis_undead,see_hide,see_improved_hide"
+
+// NPC is a struct for holding mob data like resists, mitigation, special flags
+type NPC struct {
+	id                int
+	name              string // is a clean name without underscores or #'s
+	oName             string // contains the unclean name
+	level             int
+	mr                int
+	cr                int
+	dr                int
+	fr                int
+	pr                int
+	corrup            int
+	phr               int
+	slowMitigation    int
+	specialAbilities  string
+	npcSpecialAttacks string
+	seeInvis          int
+	seeInvisUndead    int
+	seeHide           int
+	seeImprovedHide   int
+}
+
+// LookupResists shows the resist card for a mob from the EQEmu database
+func LookupResists(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("LookupResists-npc.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Resists command ran without a mob: %s", message)
+		return ""
+	}
+	db, err := getDB()
+	if err != nil {
+		return err.Error()
+	}
+	npc, choices, err := resolveNPC(strings.Join(message[1:], " "), db)
+	if err != nil {
+		l.ErrorF("Error looking up mob: %s", err.Error())
+		return err.Error()
+	}
+	if choices != "" {
+		return choices
+	}
+	return npc.resistCard()
+}
+
+// resolveNPC narrows a name (or id) search down to a single NPC, when the name
+// is ambiguous it returns a list of candidates for the user to choose from instead
+func resolveNPC(name string, db *sql.DB) (NPC, string, error) {
+	l := LogInit("resolveNPC-npc.go")
+	defer l.End()
+	name = strings.TrimSpace(name)
+	if id, err := strconv.Atoi(name); err == nil {
+		npc, err := getNPCByID(id, db)
+		if err == sql.ErrNoRows {
+			return NPC{}, "", fmt.Errorf("No mob found with id %d", id)
+		}
+		if err != nil {
+			l.ErrorF("Error looking up mob %d: %s", id, err.Error())
+			return NPC{}, "", gameDBFailed(err)
+		}
+		return npc, "", nil
+	}
+	npcs, err := getResistsByMobName(name, db)
+	if err != nil {
+		l.ErrorF("Error looking up mob %s: %s", name, err.Error())
+		return NPC{}, "", gameDBFailed(err)
+	}
+	if len(npcs) == 0 {
+		return NPC{}, "", fmt.Errorf("No mob found matching %s", name)
+	}
+	if len(npcs) == 1 {
+		return npcs[0], "", nil
+	}
+	var exact []NPC
+	for _, npc := range npcs {
+		if strings.EqualFold(npc.name, name) {
+			exact = append(exact, npc)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0], "", nil
+	}
+	l.InfoF("%d mobs match %s, asking user to choose", len(npcs), name)
+	var names []string
+	for _, npc := range npcs {
+		names = append(names, fmt.Sprintf("%s (level %d, id %d)", npc.name, npc.level, npc.id))
+	}
+	return NPC{}, listMatches("mobs", name, names), nil
+}
+
+// listMatches formats a list of candidates for an ambiguous search
+func listMatches(kind, search string, names []string) string {
+	found := fmt.Sprintf("%d", len(names))
+	more := ""
+	if len(names) >= maxSearchResults { // the search was capped, there may be more
+		found, more = found+"+", "+"
+	}
+	response := fmt.Sprintf("Found %s %s matching %s, which one did you mean? (you can also search by id)\n", found, kind, search)
+	for i, name := range names {
+		if i == maxMatches {
+			response = fmt.Sprintf("%s...and %d%s more\n", response, len(names)-maxMatches, more)
+			break
+		}
+		response = fmt.Sprintf("%s%s\n", response, name)
+	}
+	return response
+}
+
+func getResistsByMobName(name string, db *sql.DB) ([]NPC, error) {
+	var npcs []NPC
+	dbName := strings.ReplaceAll(name, " ", "_")
+	// Prepare statement for reading data NAME LIKE "%Invisibility%Undead"
+	stmtOut, err := db.Prepare("SELECT " + npcColumns + " FROM npc_types WHERE name LIKE ? ESCAPE '\\\\' ORDER BY TRIM(LEADING '#' FROM name) = ? DESC, name, id LIMIT ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmtOut.Close()
+	rows, err := stmtOut.Query(likeContains(dbName), dbName, maxSearchResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		npc, err := scanNPC(rows)
+		if err != nil {
+			return nil, err
+		}
+		npcs = append(npcs, npc)
+	}
+	return npcs, rows.Err()
+}
+
+func getNPCByID(id int, db *sql.DB) (NPC, error) {
+	row := db.QueryRow("SELECT "+npcColumns+" FROM npc_types WHERE id = ?", id)
+	return scanNPC(row)
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanNPC(row rowScanner) (NPC, error) {
+	var npc NPC
+	err := row.Scan(&npc.id, &npc.oName, &npc.level, &npc.mr, &npc.cr, &npc.dr, &npc.fr, &npc.pr, &npc.corrup, &npc.phr, &npc.slowMitigation, &npc.specialAbilities, &npc.npcSpecialAttacks, &npc.seeInvis, &npc.seeInvisUndead, &npc.seeHide, &npc.seeImprovedHide)
+	if err != nil {
+		return NPC{}, err
+	}
+	npc.name = cleanMobName(npc.oName)
+	return npc, nil
+}
+
+// cleanMobName turns a database name like #Lord_Nagafen into Lord Nagafen
+func cleanMobName(name string) string {
+	name = strings.TrimLeft(name, "#")
+	return strings.TrimSpace(strings.ReplaceAll(name, "_", " "))
+}
+
+func (npc NPC) resistCard() string {
+	response := fmt.Sprintf("%s (level %d, id %d)\n", npc.name, npc.level, npc.id)
+	response = fmt.Sprintf("%sMagic: %d\tCold: %d\tDisease: %d\tFire: %d\tPoison: %d\n", response, npc.mr, npc.cr, npc.dr, npc.fr, npc.pr)
+	response = fmt.Sprintf("%sCorruption: %d\tPhysical: %d\n", response, npc.corrup, npc.phr)
+	response = fmt.Sprintf("%sSlow Mitigation: %d%%\n", response, npc.slowMitigation)
+	return response + npc.traitLine()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Reactions used to turn pages
+const (
+	pagePrevious = "◀️"
+	pageNext     = "▶️"
+)
+
+// pageTimeout is how long a paged message can be turned before we forget it
+const pageTimeout = 10 * time.Minute
+
+// PagedMessage is a message whose content can be paged through with reactions, each
+// page is its text and an embed
+type PagedMessage struct {
+	channelID string
+	pages     []BotResponse
+	page      int
+	text      bool // embeds couldn't be sent, page with text instead
+}
+
+// pagedMessages are the paged messages that can still be turned, keyed by message ID
+var pagedMessages struct {
+	sync.Mutex
+	messages map[string]*PagedMessage
+}
+
+// sendPages sends the first page to a channel and adds reactions to turn the rest
+func sendPages(s *discordgo.Session, channelID string, pages []BotResponse) error {
+	l := LogInit("sendPages-pages.go")
+	defer l.End()
+	paged := &PagedMessage{channelID: channelID, pages: pages}
+	var msg *discordgo.Message
+	var err error
+	if embed := paged.embed(); embed != nil {
+		msg, err = s.ChannelMessageSendEmbed(channelID, embed)
+		if err != nil {
+			l.ErrorF("Unable to send page embed, falling back to text: %s", err.Error())
+		}
+	}
+	if msg == nil {
+		paged.text = true
+		msg, err = s.ChannelMessageSend(channelID, paged.content())
+	}
+	if err != nil {
+		return err
+	}
+	if len(pages) < 2 {
+		return nil
+	}
+	pagedMessages.Lock()
+	if pagedMessages.messages == nil {
+		pagedMessages.messages = make(map[string]*PagedMessage)
+	}
+	pagedMessages.messages[msg.ID] = paged
+	pagedMessages.Unlock()
+	time.AfterFunc(pageTimeout, func() {
+		pagedMessages.Lock()
+		delete(pagedMessages.messages, msg.ID)
+		pagedMessages.Unlock()
+	})
+	for _, emoji := range []string{pagePrevious, pageNext} {
+		if err := s.MessageReactionAdd(channelID, msg.ID, emoji); err != nil {
+			l.ErrorF("Unable to add page reactions: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+func (pm *PagedMessage) content() string {
+	if len(pm.pages) < 2 {
+		return pm.pages[0].text
+	}
+	return fmt.Sprintf("%sPage %d/%d", pm.pages[pm.page].text, pm.page+1, len(pm.pages))
+}
+
+// embed is the current page's embed with the page number in the footer, nil if the page
+// has none or embeds are turned off
+func (pm *PagedMessage) embed() *discordgo.MessageEmbed {
+	if pm.text || configuration.PlainTextResponses || len(pm.pages[pm.page].embeds) == 0 {
+		return nil
+	}
+	embed := *pm.pages[pm.page].embeds[0]
+	if len(pm.pages) > 1 {
+		footer := fmt.Sprintf("Page %d/%d", pm.page+1, len(pm.pages))
+		if embed.Footer != nil && embed.Footer.Text != "" {
+			footer = fmt.Sprintf("%s, %s", footer, embed.Footer.Text)
+		}
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+	}
+	return &embed
+}
+
+// turnPage is called for every reaction, it turns paged messages when someone reacts with the arrows
+func turnPage(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	l := LogInit("turnPage-pages.go")
+	defer l.End()
+	if r.UserID == s.State.User.ID {
+		return
+	}
+	pagedMessages.Lock()
+	paged, ok := pagedMessages.messages[r.MessageID]
+	if !ok {
+		pagedMessages.Unlock()
+		return
+	}
+	switch r.Emoji.Name {
+	case pagePrevious:
+		paged.page = (paged.page + len(paged.pages) - 1) % len(paged.pages)
+	case pageNext:
+		paged.page = (paged.page + 1) % len(paged.pages)
+	default:
+		pagedMessages.Unlock()
+		return
+	}
+	content, embed := paged.content(), paged.embed()
+	pagedMessages.Unlock()
+	var err error
+	if embed != nil {
+		_, err = s.ChannelMessageEditEmbed(r.ChannelID, r.MessageID, embed)
+	} else {
+		_, err = s.ChannelMessageEdit(r.ChannelID, r.MessageID, content)
+	}
+	if err != nil {
+		l.ErrorF("Unable to turn page: %s", err.Error())
+	}
+	if err := s.MessageReactionRemove(r.ChannelID, r.MessageID, r.Emoji.Name, r.UserID); err != nil {
+		l.TraceF("Unable to remove page reaction, probably a DM: %s", err.Error())
+	}
+}
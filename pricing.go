@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pricing rules for AuctionPricingRule
+const (
+	pricingFirst    = "first"    // the winner pays what they bid
+	pricingVickrey  = "vickrey"  // the winner pays the second highest bid plus one
+	pricingTier     = "tier"     // the winner pays a fixed cost for the item's tier
+	pricingRankCaps = "rankcaps" // the winner pays what they bid, bids are capped by rank
+)
+
+// defaultItemTier is the tier of items not listed in ItemTiers
+const defaultItemTier = "default"
+
+// PricingRule decides which bids are allowed and what the winner pays
+type PricingRule interface {
+	name() string
+	validate(auction *Auction, bid Bid) error
+	price(auction *Auction, winner Bid) int
+}
+
+// newPricingRule returns the configured pricing rule, first price if it isn't set
+func newPricingRule() (PricingRule, error) {
+	switch strings.ToLower(configuration.AuctionPricingRule) {
+	case "", pricingFirst:
+		return firstPrice{}, nil
+	case pricingVickrey:
+		return vickreyPrice{}, nil
+	case pricingTier:
+		return tierPrice{}, nil
+	case pricingRankCaps:
+		return rankCapPrice{}, nil
+	}
+	return nil, fmt.Errorf("unknown AuctionPricingRule %s", configuration.AuctionPricingRule)
+}
+
+// minimumBid is the least anyone can bid
+func minimumBid() int {
+	if configuration.AuctionMinimumBid > 0 {
+		return configuration.AuctionMinimumBid
+	}
+	return 1
+}
+
+type firstPrice struct{}
+
+func (firstPrice) name() string { return "first price" }
+
+func (firstPrice) validate(auction *Auction, bid Bid) error {
+	if bid.amount < minimumBid() {
+		return fmt.Errorf("The minimum bid is %d", minimumBid())
+	}
+	return nil
+}
+
+func (firstPrice) price(auction *Auction, winner Bid) int {
+	return winner.amount
+}
+
+type vickreyPrice struct{ firstPrice }
+
+func (vickreyPrice) name() string { return "second price" }
+
+func (vickreyPrice) price(auction *Auction, winner Bid) int {
+	second := minimumBid() - 1
+	for _, bid := range auction.bids {
+		if bid.player.name != winner.player.name && bid.amount > second {
+			second = bid.amount
+		}
+	}
+	if second+1 > winner.amount {
+		return winner.amount
+	}
+	return second + 1
+}
+
+type tierPrice struct{}
+
+func (tierPrice) name() string { return "tier price" }
+
+func (tierPrice) validate(auction *Auction, bid Bid) error {
+	if cost := itemTierCost(auction.item); bid.amount < cost {
+		return fmt.Errorf("%s costs %d, bid at least that much", auction.item, cost)
+	}
+	return nil
+}
+
+func (tierPrice) price(auction *Auction, winner Bid) int {
+	return itemTierCost(auction.item)
+}
+
+// itemTierCost is the fixed cost of an item's tier from ItemTiers and TierCosts
+func itemTierCost(item string) int {
+	tier := defaultItemTier
+	for name, t := range configuration.ItemTiers {
+		if strings.EqualFold(name, item) {
+			tier = t
+			break
+		}
+	}
+	if cost, ok := configuration.TierCosts[tier]; ok {
+		return cost
+	}
+	return minimumBid()
+}
+
+type rankCapPrice struct{ firstPrice }
+
+func (rankCapPrice) name() string { return "rank capped" }
+
+func (r rankCapPrice) validate(auction *Auction, bid Bid) error {
+	if err := r.firstPrice.validate(auction, bid); err != nil {
+		return err
+	}
+	if max := rankBidCap(bid.player); bid.amount > max {
+		return fmt.Errorf("%s can bid at most %d as a %s", bid.player.name, max, bid.player.rank)
+	}
+	return nil
+}
+
+// rankBidCap is the most a player can bid, RankBidCaps is a percentage of their DKP by rank
+func rankBidCap(player Player) int {
+	for rank, percent := range configuration.RankBidCaps {
+		if strings.EqualFold(rank, player.rank) {
+			return player.dkp * percent / 100
+		}
+	}
+	return player.dkp
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPricingRules(t *testing.T) {
+	alpha := Player{name: "Alpha", rank: "Member", dkp: 200}
+	bravo := Player{name: "Bravo", rank: "Recruit", dkp: 200}
+	charlie := Player{name: "Charlie", rank: "Member", dkp: 200}
+	tests := []struct {
+		name     string
+		rule     string
+		item     string
+		bids     []Bid
+		bid      Bid // validated against the auction, then placed and priced as the winner
+		minimum  int
+		validErr bool
+		price    int
+	}{
+		{
+			name:  "first price pays the bid",
+			rule:  "",
+			bids:  []Bid{{player: bravo, amount: 40}},
+			bid:   Bid{player: alpha, amount: 50},
+			price: 50,
+		},
+		{
+			name:     "first price minimum bid",
+			rule:     pricingFirst,
+			bid:      Bid{player: alpha, amount: 4},
+			minimum:  5,
+			validErr: true,
+		},
+		{
+			name:  "vickrey pays the second bid plus one",
+			rule:  pricingVickrey,
+			bids:  []Bid{{player: bravo, amount: 40}, {player: charlie, amount: 25}},
+			bid:   Bid{player: alpha, amount: 50},
+			price: 41,
+		},
+		{
+			name:  "vickrey with a tie pays the bid",
+			rule:  pricingVickrey,
+			bids:  []Bid{{player: bravo, amount: 50}},
+			bid:   Bid{player: alpha, amount: 50},
+			price: 50,
+		},
+		{
+			name:    "vickrey alone pays the minimum",
+			rule:    pricingVickrey,
+			bid:     Bid{player: alpha, amount: 50},
+			minimum: 10,
+			price:   10,
+		},
+		{
+			name:  "tier price pays the tier cost",
+			rule:  pricingTier,
+			item:  "Cloak of Flames",
+			bid:   Bid{player: alpha, amount: 100},
+			price: 60,
+		},
+		{
+			name:  "unlisted items are the default tier",
+			rule:  pricingTier,
+			item:  "Rusty Dagger",
+			bid:   Bid{player: alpha, amount: 100},
+			price: 20,
+		},
+		{
+			name:     "tier price bid under the cost",
+			rule:     pricingTier,
+			item:     "cloak of flames",
+			bid:      Bid{player: alpha, amount: 59},
+			validErr: true,
+		},
+		{
+			name:  "rank cap allows bids up to the cap",
+			rule:  pricingRankCaps,
+			bid:   Bid{player: bravo, amount: 100},
+			price: 100,
+		},
+		{
+			name:     "rank cap refuses bids over the cap",
+			rule:     pricingRankCaps,
+			bid:      Bid{player: bravo, amount: 101},
+			validErr: true,
+		},
+		{
+			name:  "unlisted ranks aren't capped",
+			rule:  pricingRankCaps,
+			bid:   Bid{player: alpha, amount: 200},
+			price: 200,
+		},
+	}
+	defer func() {
+		configuration.AuctionPricingRule = ""
+		configuration.AuctionMinimumBid = 0
+		configuration.ItemTiers = nil
+		configuration.TierCosts = nil
+		configuration.RankBidCaps = nil
+	}()
+	configuration.ItemTiers = map[string]string{"Cloak of Flames": "raid"}
+	configuration.TierCosts = map[string]int{"raid": 60, defaultItemTier: 20}
+	configuration.RankBidCaps = map[string]int{"recruit": 50}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configuration.AuctionPricingRule = tt.rule
+			configuration.AuctionMinimumBid = tt.minimum
+			rule, err := newPricingRule()
+			if err != nil {
+				t.Fatal(err)
+			}
+			auction := &Auction{item: tt.item, bids: append([]Bid(nil), tt.bids...), rule: rule}
+			err = rule.validate(auction, tt.bid)
+			if tt.validErr {
+				if err == nil {
+					t.Fatalf("%s allowed a bid of %d", rule.name(), tt.bid.amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			auction.placeBid(tt.bid)
+			if price := rule.price(auction, tt.bid); price != tt.price {
+				t.Errorf("price = %d, want %d", price, tt.price)
+			}
+		})
+	}
+	configuration.AuctionPricingRule = "dutch"
+	if _, err := newPricingRule(); err == nil {
+		t.Error("an unknown pricing rule was accepted")
+	}
+}
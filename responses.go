@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord limits
+const (
+	maxDiscordMessage   = 2000
+	maxEmbedTitle       = 256
+	maxEmbedDescription = 2048
+	maxEmbedFields      = 25
+)
+
+// defaultEmbedColor is Discord blurple, used when an embed isn't about one class
+const defaultEmbedColor = 0x7289da
+
+// classColors colour embeds about a single class
+var classColors = map[string]int{
+	"warrior":       0xc79c6e,
+	"cleric":        0xf0ebe0,
+	"paladin":       0xf58cba,
+	"ranger":        0xabd473,
+	"shadow knight": 0xc41f3b,
+	"druid":         0xff7d0a,
+	"monk":          0x00ff96,
+	"bard":          0xe6cc80,
+	"rogue":         0xfff569,
+	"shaman":        0x0070de,
+	"necromancer":   0x8e44ad,
+	"wizard":        0x69ccf0,
+	"magician":      0xe67e22,
+	"enchanter":     0xa330c9,
+	"beastlord":     0x8b5a2b,
+	"berserker":     0xa52a2a,
+}
+
+// BotResponse is what a command answers with. text is always filled in, it's sent when
+// embeds are turned off or can't be sent
+type BotResponse struct {
+	text   string
+	embeds []*discordgo.MessageEmbed
+	files  []ResponseFile
+}
+
+// ResponseFile is a file attached to a response, kept as bytes so it can be sent again
+// if the first try fails
+type ResponseFile struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+// textResponse is a plain text response, it's turned into an embed when sent
+func textResponse(text string) BotResponse {
+	return BotResponse{text: text}
+}
+
+// textAction lets a command that answers in plain text be used as a BotAction
+func textAction(action func(s *discordgo.Session, m *discordgo.MessageCreate, message []string) string) BotAction {
+	return func(s *discordgo.Session, m *discordgo.MessageCreate, message []string) BotResponse {
+		return textResponse(action(s, m, message))
+	}
+}
+
+func (br BotResponse) empty() bool {
+	return br.text == "" && len(br.embeds) == 0 && len(br.files) == 0
+}
+
+// classColor is the colour of the class shared by every player, or the default colour
+func classColor(players ...Player) int {
+	if len(players) == 0 {
+		return defaultEmbedColor
+	}
+	class := strings.TrimSpace(strings.ToLower(players[0].class))
+	for _, player := range players[1:] {
+		if strings.TrimSpace(strings.ToLower(player.class)) != class {
+			return defaultEmbedColor
+		}
+	}
+	if color, ok := classColors[class]; ok {
+		return color
+	}
+	return defaultEmbedColor
+}
+
+// dataFooter tells users how old the DKP data in an embed is
+func dataFooter() *discordgo.MessageEmbedFooter {
+	return &discordgo.MessageEmbedFooter{Text: strings.Trim(rosterAge(), "()")}
+}
+
+// embed shows a main's DKP with their alts
+func (g PlayerGroup) embed() *discordgo.MessageEmbed {
+	dkp := fmt.Sprintf("%d", g.dkp())
+	if combinedAlts() && len(g.alts) > 0 {
+		dkp += " combined"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: g.main.name,
+		Color: classColor(g.main),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "DKP", Value: dkp, Inline: true},
+			{Name: "Rank", Value: orNone(g.main.rank), Inline: true},
+			{Name: "Class", Value: orNone(strings.Title(g.main.class)), Inline: true},
+			{Name: "Attendance", Value: orNone(g.main.attendance), Inline: true},
+			{Name: "Last Raid", Value: orNone(g.main.lastRaid), Inline: true},
+		},
+		Footer: dataFooter(),
+	}
+	if len(g.alts) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Alts", Value: g.altLines()})
+	}
+	return embed
+}
+
+// field is a main and their alts as one embed field
+func (g PlayerGroup) field() *discordgo.MessageEmbedField {
+	value := fmt.Sprintf("%d DKP", g.dkp())
+	if combinedAlts() && len(g.alts) > 0 {
+		value += " combined"
+	}
+	if len(g.alts) > 0 {
+		value = fmt.Sprintf("%s\n%s", value, g.altLines())
+	}
+	return &discordgo.MessageEmbedField{Name: fmt.Sprintf("%s (%s)", g.main.name, g.main.rank), Value: value}
+}
+
+// altLines lists the group's alts, one per line
+func (g PlayerGroup) altLines() string {
+	sort.Sort(sort.Reverse(byDKP(g.alts)))
+	var lines []string
+	for _, alt := range g.alts {
+		line := fmt.Sprintf("%s: %d", alt.name, alt.dkp)
+		if g.raidedInstead(alt) {
+			line = fmt.Sprintf("%s (raided in place of %s on %s)", line, g.main.name, alt.lastRaid)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// orNone keeps embed fields from being empty, which Discord rejects
+func orNone(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "None"
+	}
+	return value
+}
+
+// fieldEmbeds spreads fields over as many embeds as Discord needs
+func fieldEmbeds(title string, color int, fields []*discordgo.MessageEmbedField, footer *discordgo.MessageEmbedFooter) []*discordgo.MessageEmbed {
+	var embeds []*discordgo.MessageEmbed
+	for start := 0; start < len(fields) || start == 0; start += maxEmbedFields {
+		end := start + maxEmbedFields
+		if end > len(fields) {
+			end = len(fields)
+		}
+		embed := &discordgo.MessageEmbed{Title: title, Color: color, Fields: fields[start:end], Footer: footer}
+		if start > 0 {
+			embed.Title = title + " (continued)"
+		}
+		embeds = append(embeds, embed)
+		if end == len(fields) {
+			break
+		}
+	}
+	return embeds
+}
+
+// embedsFromText turns a plain text response into embeds, the first line becomes the title
+// when there's more than one line
+func embedsFromText(text string) []*discordgo.MessageEmbed {
+	text = strings.TrimSpace(strings.NewReplacer(":\t", ": ", "\t", "  ").Replace(text))
+	if text == "" {
+		return nil
+	}
+	var title string
+	if lines := strings.SplitN(text, "\n", 2); len(lines) == 2 && len(lines[0]) <= maxEmbedTitle {
+		title, text = lines[0], strings.TrimSpace(lines[1])
+	}
+	var embeds []*discordgo.MessageEmbed
+	for i, chunk := range chunkText(text, maxEmbedDescription) {
+		embed := &discordgo.MessageEmbed{Description: chunk, Color: defaultEmbedColor}
+		if i == 0 {
+			embed.Title = title
+		}
+		embeds = append(embeds, embed)
+	}
+	return embeds
+}
+
+// sendResponse sends a response to a channel as embeds, falling back to plain text when
+// embeds are turned off or Discord won't take them
+func sendResponse(s *discordgo.Session, channelID string, response BotResponse) {
+	l := LogInit("sendResponse-responses.go")
+	defer l.End()
+	if response.empty() {
+		return
+	}
+	if !configuration.PlainTextResponses {
+		embeds := response.embeds
+		if len(embeds) == 0 {
+			embeds = embedsFromText(response.text)
+		}
+		for i, embed := range embeds {
+			msg := &discordgo.MessageSend{Embed: embed}
+			if i == 0 {
+				msg.Files = response.discordFiles()
+			}
+			if _, err := s.ChannelMessageSendComplex(channelID, msg); err != nil {
+				l.ErrorF("Unable to send embed, falling back to text: %s", err.Error())
+				if i == 0 {
+					break
+				}
+				// the first embeds and the files are already sent, only the rest go as text
+				var rest []string
+				for _, unsent := range embeds[i:] {
+					rest = append(rest, embedText(unsent))
+				}
+				sendText(s, channelID, textResponse(strings.Join(rest, "\n")))
+				return
+			}
+			if i == len(embeds)-1 {
+				return
+			}
+		}
+	}
+	sendText(s, channelID, response)
+}
+
+// embedText is an embed written out as plain text
+func embedText(embed *discordgo.MessageEmbed) string {
+	var lines []string
+	if embed.Title != "" {
+		lines = append(lines, embed.Title)
+	}
+	if embed.Description != "" {
+		lines = append(lines, embed.Description)
+	}
+	for _, field := range embed.Fields {
+		lines = append(lines, fmt.Sprintf("%s: %s", field.Name, strings.ReplaceAll(field.Value, "\n", ", ")))
+	}
+	if embed.Footer != nil && embed.Footer.Text != "" {
+		lines = append(lines, "("+embed.Footer.Text+")")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sendText sends a response as plain text in as many messages as it needs
+func sendText(s *discordgo.Session, channelID string, response BotResponse) {
+	l := LogInit("sendText-responses.go")
+	defer l.End()
+	chunks := chunkMessages(response.text)
+	if len(chunks) == 0 {
+		chunks = []string{""}
+	}
+	if len(chunks) > 1 {
+		l.InfoF("Message too long, breaking it into %d messages", len(chunks))
+	}
+	for i, chunk := range chunks {
+		msg := &discordgo.MessageSend{Content: chunk}
+		if i == 0 {
+			msg.Files = response.discordFiles()
+		}
+		if msg.Content == "" && len(msg.Files) == 0 {
+			continue
+		}
+		if _, err := s.ChannelMessageSendComplex(channelID, msg); err != nil {
+			l.ErrorF("Unable to send message: %s", err.Error())
+		}
+	}
+}
+
+func (br BotResponse) discordFiles() []*discordgo.File {
+	var files []*discordgo.File
+	for _, file := range br.files {
+		files = append(files, &discordgo.File{Name: file.name, ContentType: file.contentType, Reader: bytes.NewReader(file.data)})
+	}
+	return files
+}
+
+// chunkMessages breaks a response into messages no longer than MaxMessageLength
+func chunkMessages(r string) []string {
+	limit := configuration.MaxMessageLength
+	if limit <= 0 || limit > maxDiscordMessage {
+		limit = maxDiscordMessage
+	}
+	return chunkText(r, limit)
+}
+
+// chunkText breaks text into pieces no longer than limit, splitting between lines where it
+// can, then between words, and only cutting words longer than limit
+func chunkText(text string, limit int) []string {
+	var chunks []string
+	var chunk string
+	add := func(piece, sep string) {
+		if chunk == "" {
+			chunk = piece
+			return
+		}
+		if len(chunk)+len(sep)+len(piece) > limit {
+			chunks = append(chunks, chunk)
+			chunk = piece
+			return
+		}
+		chunk += sep + piece
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if len(line) <= limit {
+			add(line, "\n")
+			continue
+		}
+		sep := "\n"
+		for _, word := range strings.Split(line, " ") {
+			for len(word) > limit {
+				add(word[:limit], sep)
+				word, sep = word[limit:], " "
+			}
+			add(word, sep)
+			sep = " "
+		}
+	}
+	if strings.TrimSpace(chunk) != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
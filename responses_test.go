@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"fits", "one\ntwo", 10, []string{"one\ntwo"}},
+		{"empty", "", 10, nil},
+		{"split between lines", "aaaa\nbbbb\ncccc", 9, []string{"aaaa\nbbbb", "cccc"}},
+		{"split between words", "aaa bbb ccc", 7, []string{"aaa bbb", "ccc"}},
+		{"long line after a short one", "aa\nbbb ccc", 5, []string{"aa", "bbb", "ccc"}},
+		{"cut a word longer than limit", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"exactly the limit", "abcd\nefgh", 4, []string{"abcd", "efgh"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chunkText(tt.text, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunkText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+			for _, chunk := range got {
+				if len(chunk) > tt.limit {
+					t.Errorf("chunk %q is longer than %d", chunk, tt.limit)
+				}
+			}
+		})
+	}
+}
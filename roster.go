@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// bucketRoster holds the last rosterSnapshot so we have data right after a restart
+const bucketRoster = "roster"
+
+// rosterSnapshotKey is the key of the snapshot in bucketRoster
+const rosterSnapshotKey = "snapshot"
+
+// defaultRosterRefresh is used when SheetRefreshMinutes isn't configured
+const defaultRosterRefresh = 5 * time.Minute
+
+// rosterCache is the last copy of the roster and DKP sheets we read
+var rosterCache struct {
+	sync.RWMutex
+	players []Player
+	fetched time.Time
+	err     error // why the last refresh failed, nil if it worked
+}
+
+// rosterRefreshMutex stops two refreshes hitting the sheets at once
+var rosterRefreshMutex sync.Mutex
+
+// rosterSnapshot is how the roster cache is saved in the datastore
+type rosterSnapshot struct {
+	Fetched time.Time      `json:"Fetched"`
+	Players []cachedPlayer `json:"Players"`
+}
+
+// cachedPlayer is a Player with exported fields for saving
+type cachedPlayer struct {
+	Class      string `json:"Class"`
+	Rank       string `json:"Rank"`
+	Name       string `json:"Name"`
+	Level      string `json:"Level"`
+	LastRaid   string `json:"LastRaid"`
+	Attendance string `json:"Attendance"`
+	DKP        int    `json:"DKP"`
+	Main       string `json:"Main"`
+}
+
+// lookupAllPlayer returns a copy of the cached roster, reading the sheets if we have nothing yet
+func lookupAllPlayer() []Player {
+	l := LogInit("lookupAllPlayer-roster.go")
+	defer l.End()
+	rosterCache.RLock()
+	empty := rosterCache.fetched.IsZero()
+	rosterCache.RUnlock()
+	if empty {
+		if err := refreshRoster(); err != nil {
+			l.ErrorF("Unable to load the roster: %s", err.Error())
+		}
+	}
+	rosterCache.RLock()
+	defer rosterCache.RUnlock()
+	players := make([]Player, len(rosterCache.players))
+	copy(players, rosterCache.players)
+	return players
+}
+
+// refreshRoster reads the sheets into the cache and saves a snapshot
+func refreshRoster() error {
+	l := LogInit("refreshRoster-roster.go")
+	defer l.End()
+	rosterRefreshMutex.Lock()
+	defer rosterRefreshMutex.Unlock()
+	players, err := fetchAllPlayers()
+	if err != nil {
+		rosterCache.Lock()
+		rosterCache.err = err
+		rosterCache.Unlock()
+		return err
+	}
+	fetched := time.Now()
+	rosterCache.Lock()
+	rosterCache.players = players
+	rosterCache.fetched = fetched
+	rosterCache.err = nil
+	rosterCache.Unlock()
+	l.InfoF("Roster refreshed with %d players", len(players))
+	snapshot := rosterSnapshot{Fetched: fetched}
+	for _, player := range players {
+		snapshot.Players = append(snapshot.Players, cachedPlayer{player.class, player.rank, player.name, player.level, player.lastRaid, player.attendance, player.dkp, player.main})
+	}
+	if err := store.Put(bucketRoster, rosterSnapshotKey, snapshot); err != nil {
+		l.ErrorF("Unable to save roster snapshot: %s", err.Error())
+	}
+	return nil
+}
+
+// loadRosterSnapshot fills the cache with the snapshot saved before we restarted
+func loadRosterSnapshot() error {
+	var snapshot rosterSnapshot
+	found, err := store.Get(bucketRoster, rosterSnapshotKey, &snapshot)
+	if err != nil || !found {
+		return err
+	}
+	var players []Player
+	for _, p := range snapshot.Players {
+		players = append(players, Player{class: p.Class, rank: p.Rank, name: p.Name, level: p.Level, lastRaid: p.LastRaid, attendance: p.Attendance, dkp: p.DKP, main: p.Main})
+	}
+	rosterCache.Lock()
+	defer rosterCache.Unlock()
+	rosterCache.players = players
+	rosterCache.fetched = snapshot.Fetched
+	return nil
+}
+
+// watchRoster refreshes the roster cache on the configured interval, it never returns
+func watchRoster() {
+	l := LogInit("watchRoster-roster.go")
+	defer l.End()
+	interval := defaultRosterRefresh
+	if configuration.SheetRefreshMinutes > 0 {
+		interval = time.Duration(configuration.SheetRefreshMinutes) * time.Minute
+	}
+	for {
+		if err := refreshRoster(); err != nil {
+			l.ErrorF("Unable to refresh the roster: %s", err.Error())
+		}
+		time.Sleep(interval)
+	}
+}
+
+// rosterAge tells users how old the DKP data they're looking at is
+func rosterAge() string {
+	rosterCache.RLock()
+	fetched := rosterCache.fetched
+	err := rosterCache.err
+	rosterCache.RUnlock()
+	var layoutErr *layoutError
+	if errors.As(err, &layoutErr) {
+		if fetched.IsZero() {
+			return fmt.Sprintf("(no DKP data loaded, %s)", layoutErr.Error())
+		}
+		return fmt.Sprintf("(data as of %v ago, %s)", time.Since(fetched).Round(time.Second), layoutErr.Error())
+	}
+	if fetched.IsZero() {
+		return "(no DKP data loaded)"
+	}
+	return fmt.Sprintf("(data as of %v ago)", time.Since(fetched).Round(time.Second))
+}
+
+// RefreshRoster rereads the roster and DKP sheets right now
+func RefreshRoster(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("RefreshRoster-roster.go")
+	defer l.End()
+	if err := refreshRoster(); err != nil {
+		l.ErrorF("Unable to refresh the roster: %s", err.Error())
+		var layoutErr *layoutError
+		if errors.As(err, &layoutErr) {
+			return layoutErr.Error()
+		}
+		return "Unable to read the DKP sheets, still using " + rosterAge()
+	}
+	return "DKP data refreshed"
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Spawn is a spawn point (spawn2) a mob can pop at
+type Spawn struct {
+	id             int
+	spawngroupID   int
+	spawngroupName string
+	zone           string
+	x              float64
+	y              float64
+	z              float64
+	respawn        time.Duration
+	variance       time.Duration
+	chance         int // percent chance the mob is picked from the spawngroup
+	placeholders   []Placeholder
+}
+
+// Placeholder is another mob sharing a spawngroup with the one we looked up
+type Placeholder struct {
+	npcID  int
+	name   string
+	chance int
+}
+
+// LookupSpawn shows where a mob spawns, how often, and what shares its spawn point
+func LookupSpawn(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("LookupSpawn-spawn.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Spawn command ran without a mob: %s", message)
+		return ""
+	}
+	db, err := getDB()
+	if err != nil {
+		return err.Error()
+	}
+	npc, choices, err := resolveNPC(strings.Join(message[1:], " "), db)
+	if err != nil {
+		return err.Error()
+	}
+	if choices != "" {
+		return choices
+	}
+	spawns, err := getSpawnsByNPC(npc.id, db)
+	if err != nil {
+		l.ErrorF("Error looking up spawns for %d: %s", npc.id, err.Error())
+		return gameDBFailed(err).Error()
+	}
+	if len(spawns) == 0 {
+		return fmt.Sprintf("%s has no spawn points", npc.name)
+	}
+	response = fmt.Sprintf("%s (id %d)\n%sSpawns:\n", npc.name, npc.id, npc.traitLine())
+	for _, spawn := range spawns {
+		response = fmt.Sprintf("%s%s at /loc %.0f, %.0f, %.0f (%d%% chance)\n", response, spawn.zone, spawn.y, spawn.x, spawn.z, spawn.chance)
+		response = fmt.Sprintf("%s\tRespawn: %v", response, spawn.respawn)
+		if spawn.variance > 0 {
+			response = fmt.Sprintf("%s +/- %v", response, spawn.variance/2)
+		}
+		response += "\n"
+		if len(spawn.placeholders) > 0 {
+			var placeholders []string
+			for _, ph := range spawn.placeholders {
+				placeholders = append(placeholders, fmt.Sprintf("%s (%d%%)", ph.name, ph.chance))
+			}
+			response = fmt.Sprintf("%s\tPlaceholders: %s\n", response, strings.Join(placeholders, ", "))
+		}
+	}
+	return response
+}
+
+// getSpawnsByNPC returns every spawn point the mob can pop at along with its placeholders
+func getSpawnsByNPC(npcID int, db *sql.DB) ([]Spawn, error) {
+	rows, err := db.Query(`SELECT s2.id, s2.spawngroupID, sg.name, s2.zone, s2.x, s2.y, s2.z, s2.respawntime, s2.variance, se.chance
+		FROM spawnentry se
+		JOIN spawngroup sg ON sg.id = se.spawngroupID
+		JOIN spawn2 s2 ON s2.spawngroupID = se.spawngroupID
+		WHERE se.npcID = ?
+		ORDER BY s2.zone, s2.id`, npcID)
+	if err != nil {
+		return nil, err
+	}
+	var spawns []Spawn
+	for rows.Next() {
+		var spawn Spawn
+		var respawn, variance int
+		if err := rows.Scan(&spawn.id, &spawn.spawngroupID, &spawn.spawngroupName, &spawn.zone, &spawn.x, &spawn.y, &spawn.z, &respawn, &variance, &spawn.chance); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		spawn.respawn = time.Duration(respawn) * time.Second
+		spawn.variance = time.Duration(variance) * time.Second
+		spawns = append(spawns, spawn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range spawns {
+		spawns[i].placeholders, err = getPlaceholders(spawns[i].spawngroupID, npcID, db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return spawns, nil
+}
+
+// getPlaceholders returns the other mobs in a spawngroup
+func getPlaceholders(spawngroupID, npcID int, db *sql.DB) ([]Placeholder, error) {
+	rows, err := db.Query(`SELECT n.id, n.name, se.chance
+		FROM spawnentry se
+		JOIN npc_types n ON n.id = se.npcID
+		WHERE se.spawngroupID = ? AND se.npcID <> ?
+		ORDER BY se.chance DESC`, spawngroupID, npcID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var placeholders []Placeholder
+	for rows.Next() {
+		var ph Placeholder
+		if err := rows.Scan(&ph.npcID, &ph.name, &ph.chance); err != nil {
+			return nil, err
+		}
+		ph.name = cleanMobName(ph.name)
+		placeholders = append(placeholders, ph)
+	}
+	return placeholders, rows.Err()
+}
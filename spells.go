@@ -0,0 +1,522 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// spellEffectSlots is how many effect slots spells_new has
+const spellEffectSlots = 12
+
+// spellBlankEffect is the effect id EQEmu uses for an unused slot
+const spellBlankEffect = 254
+
+// spellTick is how long a buff tick lasts
+const spellTick = 6 * time.Second
+
+// Spell is an entry from the EQEmu spells_new table
+type Spell struct {
+	id           int
+	name         string
+	mana         int
+	castTime     int // milliseconds
+	recoveryTime int // milliseconds
+	recastTime   int // milliseconds
+	durationForm int
+	duration     int // ticks
+	resistType   int
+	resistDiff   int
+	targetType   int
+	classes      [16]int // level each class gets the spell, 255 if they can't use it
+	effects      [spellEffectSlots]int
+	baseValues   [spellEffectSlots]int
+	maxValues    [spellEffectSlots]int
+}
+
+var spellResistTypes = map[int]string{
+	0: "Unresistable",
+	1: "Magic",
+	2: "Fire",
+	3: "Cold",
+	4: "Poison",
+	5: "Disease",
+	6: "Chromatic",
+	7: "Prismatic",
+	8: "Physical",
+	9: "Corruption",
+}
+
+var spellTargetTypes = map[int]string{
+	1:  "Line of Sight",
+	2:  "Caster AE (Player Version)",
+	3:  "Group Teleport",
+	4:  "Caster PB AE",
+	5:  "Single",
+	6:  "Self",
+	8:  "Targeted AE",
+	9:  "Animal",
+	10: "Undead",
+	11: "Summoned",
+	13: "Lifetap",
+	14: "Pet",
+	15: "Corpse",
+	16: "Plant",
+	17: "Giant",
+	18: "Dragon",
+	20: "Targeted AE Lifetap",
+	24: "Undead AE",
+	25: "Summoned AE",
+	40: "Bard AE",
+	41: "Group",
+	42: "Directional Cone",
+	43: "Group with Pets",
+	44: "Beam",
+	45: "Ring",
+	46: "Target's Target",
+	47: "Pet's Owner",
+	50: "Targeted AE (No Players or Pets)",
+}
+
+var spellEffectNames = map[int]string{
+	0:   "Current HP",
+	1:   "AC",
+	2:   "ATK",
+	3:   "Movement Speed",
+	4:   "STR",
+	5:   "DEX",
+	6:   "AGI",
+	7:   "STA",
+	8:   "INT",
+	9:   "WIS",
+	10:  "CHA",
+	11:  "Attack Speed",
+	12:  "Invisibility",
+	13:  "See Invisible",
+	14:  "Water Breathing",
+	15:  "Current Mana",
+	18:  "Pacify",
+	20:  "Blind",
+	21:  "Stun",
+	22:  "Charm",
+	23:  "Fear",
+	24:  "Stamina",
+	25:  "Bind Affinity",
+	26:  "Gate",
+	27:  "Cancel Magic",
+	28:  "Invisibility vs Undead",
+	29:  "Invisibility vs Animals",
+	30:  "Frenzy Radius",
+	31:  "Mesmerize",
+	32:  "Summon Item",
+	33:  "Summon Pet",
+	35:  "Disease Counter",
+	36:  "Poison Counter",
+	40:  "Divine Aura",
+	41:  "Destroy",
+	42:  "Shadow Step",
+	46:  "Fire Resist",
+	47:  "Cold Resist",
+	48:  "Poison Resist",
+	49:  "Disease Resist",
+	50:  "Magic Resist",
+	52:  "Sense Undead",
+	55:  "Absorb Melee Damage",
+	57:  "Levitate",
+	58:  "Illusion",
+	59:  "Damage Shield",
+	63:  "Memory Blur",
+	64:  "Spin Stun",
+	65:  "Infravision",
+	66:  "Ultravision",
+	67:  "Eye of Zomm",
+	68:  "Reclaim Pet",
+	69:  "Max HP",
+	71:  "Summon Undead Pet",
+	73:  "Bind Sight",
+	74:  "Feign Death",
+	75:  "Voice Graft",
+	76:  "Sentinel",
+	77:  "Locate Corpse",
+	78:  "Absorb Spell Damage",
+	79:  "Current HP Once",
+	81:  "Resurrect",
+	82:  "Summon Player",
+	83:  "Teleport",
+	84:  "Gravity Flux",
+	85:  "Add Proc",
+	86:  "Reaction Radius",
+	87:  "Magnification",
+	88:  "Evacuate",
+	89:  "Player Size",
+	91:  "Summon Corpse",
+	92:  "Hate",
+	94:  "Fade on Combat",
+	95:  "Sacrifice",
+	96:  "Silence",
+	97:  "Max Mana",
+	98:  "Haste v2",
+	99:  "Root",
+	100: "Heal Over Time",
+	101: "Complete Heal",
+	102: "Fearless",
+	103: "Call Pet",
+	104: "Translocate",
+	105: "Anti-Gate",
+	106: "Summon Warder",
+	108: "Summon Familiar",
+	109: "Summon Item Into Bag",
+	111: "All Resists",
+	112: "Casting Level",
+	113: "Summon Horse",
+	114: "Hate Multiplier",
+	115: "Food and Water",
+	116: "Curse Counter",
+	119: "Overhaste",
+	120: "Healing Taken",
+	121: "Reverse Damage Shield",
+	123: "Screech",
+	124: "Spell Damage Focus",
+	125: "Healing Focus",
+	126: "Spell Resist Focus",
+	127: "Cast Time Focus",
+	128: "Duration Focus",
+	129: "Range Focus",
+	130: "Hate Focus",
+	131: "Reagent Focus",
+	132: "Mana Cost Focus",
+	145: "Banish",
+	147: "Percent Heal",
+	148: "Stacking Block",
+	149: "Stacking Overwrite",
+	150: "Death Save",
+	151: "Suspend Pet",
+	152: "Temporary Pets",
+	153: "Balance HP",
+	154: "Dispel Detrimental",
+	156: "Illusion Copy",
+	157: "Spell Damage Shield",
+	158: "Reflect",
+	159: "All Stats",
+	161: "Mitigate Spell Damage",
+	162: "Mitigate Melee Damage",
+	163: "Negate Attacks",
+	167: "Pet Power",
+	168: "Melee Mitigation",
+	169: "Critical Hit Chance",
+	171: "Crippling Blow",
+	172: "Avoidance",
+	173: "Riposte",
+	174: "Dodge",
+	175: "Parry",
+	176: "Dual Wield",
+	177: "Double Attack",
+	178: "Melee Lifetap",
+	180: "Spell Resist Chance",
+	181: "Fear Resist",
+	182: "Hundred Hands",
+	184: "Hit Chance",
+	185: "Damage Modifier",
+	186: "Minimum Damage Modifier",
+	188: "Block",
+	189: "Current Endurance",
+	190: "Max Endurance",
+	192: "Current Hate",
+	194: "Fade",
+	195: "Stun Resist",
+	196: "Strikethrough",
+	198: "Current Endurance Once",
+	200: "Proc Chance",
+	201: "Ranged Proc",
+	205: "Rampage",
+	206: "AE Taunt",
+	209: "Dispel Beneficial",
+	216: "Accuracy",
+	220: "Skill Damage Bonus",
+}
+
+// LookupSpellInfo shows a spell's data from the EQEmu database
+func LookupSpellInfo(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("LookupSpellInfo-spells.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Spell info command ran without a spell: %s", message)
+		return ""
+	}
+	db, err := getDB()
+	if err != nil {
+		return err.Error()
+	}
+	spell, choices, err := resolveSpell(strings.Join(message[1:], " "), db)
+	if err != nil {
+		return err.Error()
+	}
+	if choices != "" {
+		return choices
+	}
+	return spell.card()
+}
+
+// spellColumns builds the column list scanned by scanSpell
+func spellColumns() string {
+	columns := []string{"id", "name", "mana", "cast_time", "recovery_time", "recast_time", "buffdurationformula", "buffduration", "resisttype", "ResistDiff", "targettype"}
+	for i := 1; i <= len(eqClasses); i++ {
+		columns = append(columns, fmt.Sprintf("classes%d", i))
+	}
+	for _, prefix := range []string{"effectid", "effect_base_value", "max"} {
+		for i := 1; i <= spellEffectSlots; i++ {
+			columns = append(columns, fmt.Sprintf("%s%d", prefix, i))
+		}
+	}
+	return strings.Join(columns, ",")
+}
+
+// cleanSpellName accepts the same lowercase partial names the spell sheet lookups use,
+// and drops the scroll prefixes people copy from item names
+func cleanSpellName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, prefix := range []string{"spell:", "song:"} {
+		name = strings.TrimSpace(strings.TrimPrefix(name, prefix))
+	}
+	return name
+}
+
+// resolveSpell narrows a name (or id) search down to a single spell, when the name
+// is ambiguous it returns a list of candidates for the user to choose from instead
+func resolveSpell(name string, db *sql.DB) (Spell, string, error) {
+	l := LogInit("resolveSpell-spells.go")
+	defer l.End()
+	name = cleanSpellName(name)
+	if id, err := strconv.Atoi(name); err == nil {
+		spell, err := scanSpell(db.QueryRow("SELECT "+spellColumns()+" FROM spells_new WHERE id = ?", id))
+		if err == sql.ErrNoRows {
+			return Spell{}, "", fmt.Errorf("No spell found with id %d", id)
+		}
+		if err != nil {
+			l.ErrorF("Error looking up spell %d: %s", id, err.Error())
+			return Spell{}, "", gameDBFailed(err)
+		}
+		return spell, "", nil
+	}
+	spells, err := getSpellsByName(name, db)
+	if err != nil {
+		l.ErrorF("Error looking up spell %s: %s", name, err.Error())
+		return Spell{}, "", gameDBFailed(err)
+	}
+	if len(spells) == 0 {
+		return Spell{}, "", fmt.Errorf("No spell found matching %s", name)
+	}
+	if len(spells) == 1 {
+		return spells[0], "", nil
+	}
+	var exact []Spell
+	for _, spell := range spells {
+		if strings.EqualFold(spell.name, name) {
+			exact = append(exact, spell)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0], "", nil
+	}
+	l.InfoF("%d spells match %s, asking user to choose", len(spells), name)
+	var names []string
+	for _, spell := range spells {
+		names = append(names, fmt.Sprintf("%s (id %d)", spell.name, spell.id))
+	}
+	return Spell{}, listMatches("spells", name, names), nil
+}
+
+func getSpellsByName(name string, db *sql.DB) ([]Spell, error) {
+	var spells []Spell
+	stmtOut, err := db.Prepare("SELECT " + spellColumns() + " FROM spells_new WHERE name LIKE ? ESCAPE '\\\\' ORDER BY name = ? DESC, name, id LIMIT ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmtOut.Close()
+	rows, err := stmtOut.Query(likeContains(name), name, maxSearchResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		spell, err := scanSpell(rows)
+		if err != nil {
+			return nil, err
+		}
+		spells = append(spells, spell)
+	}
+	return spells, rows.Err()
+}
+
+func scanSpell(row rowScanner) (Spell, error) {
+	var spell Spell
+	dest := []interface{}{&spell.id, &spell.name, &spell.mana, &spell.castTime, &spell.recoveryTime, &spell.recastTime, &spell.durationForm, &spell.duration, &spell.resistType, &spell.resistDiff, &spell.targetType}
+	for i := range spell.classes {
+		dest = append(dest, &spell.classes[i])
+	}
+	for i := range spell.effects {
+		dest = append(dest, &spell.effects[i])
+	}
+	for i := range spell.baseValues {
+		dest = append(dest, &spell.baseValues[i])
+	}
+	for i := range spell.maxValues {
+		dest = append(dest, &spell.maxValues[i])
+	}
+	if err := row.Scan(dest...); err != nil {
+		return Spell{}, err
+	}
+	return spell, nil
+}
+
+// classLevels returns "Class(level)" for every class that can use the spell, lowest level first
+func (spell Spell) classLevels() ([]string, int) {
+	type classLevel struct {
+		class string
+		level int
+	}
+	var levels []classLevel
+	for i, level := range spell.classes {
+		if level > 0 && level < 255 && i < len(eqClasses) {
+			levels = append(levels, classLevel{eqClasses[i], level})
+		}
+	}
+	sort.SliceStable(levels, func(i, j int) bool { return levels[i].level < levels[j].level })
+	var formatted []string
+	for _, cl := range levels {
+		formatted = append(formatted, fmt.Sprintf("%s(%d)", strings.Title(cl.class), cl.level))
+	}
+	if len(levels) == 0 {
+		return formatted, 0
+	}
+	return formatted, levels[0].level
+}
+
+// buffTicks is EQEmu's CalcBuffDuration_formula, returning -1 for permanent buffs
+func buffTicks(formula, duration, level int) int {
+	limit := func(i int) int {
+		if i < 1 {
+			i = 1
+		}
+		if duration > 0 && i > duration {
+			return duration
+		}
+		return i
+	}
+	switch formula {
+	case 0:
+		return 0
+	case 1:
+		if level > 3 {
+			return limit(level / 2)
+		}
+		return limit(1)
+	case 2:
+		if level > 3 {
+			return limit(level/2 + 5)
+		}
+		return limit(6)
+	case 3:
+		return limit(30 * level)
+	case 4:
+		if duration > 0 {
+			return duration
+		}
+		return 50
+	case 5:
+		if duration > 0 && duration < 3 {
+			return duration
+		}
+		return 3
+	case 6:
+		return limit(level / 2)
+	case 7:
+		if duration > 0 {
+			return duration
+		}
+		return limit(level)
+	case 8:
+		return limit(level + 10)
+	case 9:
+		return limit(2*level + 10)
+	case 10:
+		return limit(3*level + 10)
+	case 11:
+		return limit(30 * (level + 3))
+	case 12:
+		if level > 7 {
+			return limit(level / 4)
+		}
+		return limit(1)
+	case 13:
+		return limit(4*level + 10)
+	case 14:
+		return limit(5 * (level + 2))
+	case 15:
+		return limit(10 * (level + 10))
+	case 50, 51:
+		return -1
+	}
+	return duration
+}
+
+// durationText describes how long the spell lasts at the given caster level
+func (spell Spell) durationText(level int) string {
+	ticks := buffTicks(spell.durationForm, spell.duration, level)
+	switch {
+	case ticks < 0:
+		return "Permanent"
+	case ticks == 0:
+		return "Instant"
+	}
+	return fmt.Sprintf("%d ticks (%v) at level %d, formula %d", ticks, time.Duration(ticks)*spellTick, level, spell.durationForm)
+}
+
+// effectText describes each used effect slot
+func (spell Spell) effectText() []string {
+	var effects []string
+	for i, effect := range spell.effects {
+		if effect == spellBlankEffect || (effect == 10 && spell.baseValues[i] == 0) { // CHA 0 is used as a placeholder
+			continue
+		}
+		name, ok := spellEffectNames[effect]
+		if !ok {
+			name = fmt.Sprintf("Effect %d", effect)
+		}
+		text := fmt.Sprintf("%d: %s %+d", i+1, name, spell.baseValues[i])
+		if spell.maxValues[i] != 0 {
+			text = fmt.Sprintf("%s (max %d)", text, spell.maxValues[i])
+		}
+		effects = append(effects, text)
+	}
+	return effects
+}
+
+func (spell Spell) card() string {
+	classes, minLevel := spell.classLevels()
+	response := fmt.Sprintf("%s (id %d)\n", spell.name, spell.id)
+	if len(classes) > 0 {
+		response = fmt.Sprintf("%sClasses: %s\n", response, strings.Join(classes, ", "))
+	}
+	response = fmt.Sprintf("%sMana: %d\tCast Time: %v\tRecast Time: %v\n", response, spell.mana, time.Duration(spell.castTime)*time.Millisecond, time.Duration(spell.recastTime)*time.Millisecond)
+	response = fmt.Sprintf("%sDuration: %s\n", response, spell.durationText(minLevel))
+	resist, ok := spellResistTypes[spell.resistType]
+	if !ok {
+		resist = fmt.Sprintf("Unknown (%d)", spell.resistType)
+	}
+	response = fmt.Sprintf("%sResist: %s (adjust %d)\n", response, resist, spell.resistDiff)
+	target, ok := spellTargetTypes[spell.targetType]
+	if !ok {
+		target = fmt.Sprintf("Unknown (%d)", spell.targetType)
+	}
+	response = fmt.Sprintf("%sTarget: %s\n", response, target)
+	for _, effect := range spell.effectText() {
+		response = fmt.Sprintf("%s%s\n", response, effect)
+	}
+	return response
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultStorePath is used when StorePath isn't configured
+const defaultStorePath = "store.json"
+
+// defaultAuditPath is used when AuditPath isn't configured
+const defaultAuditPath = "audit.log"
+
+// auditMutex keeps audit lines from interleaving
+var auditMutex sync.Mutex
+
+// Buckets in the datastore
+const (
+	bucketAudit  = "audit"  // AuditRecord keyed by sequence, moved to the audit log in schema version 3
+	bucketTimers = "timers" // RaidTimer keyed by NPC id
+)
+
+// store is the global datastore for bot state
+var store *Store
+
+// Store is an embedded, file backed datastore. Documents are kept as JSON in
+// named buckets and the whole file is rewritten after every successful update
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data storeData
+}
+
+// storeData is the on disk layout of the datastore
+type storeData struct {
+	SchemaVersion int                                   `json:"SchemaVersion"`
+	Sequences     map[string]int                        `json:"Sequences"` // last id handed out per bucket
+	Buckets       map[string]map[string]json.RawMessage `json:"Buckets"`
+}
+
+// StoreTx is a set of changes applied to the datastore together
+type StoreTx struct {
+	data *storeData
+}
+
+// storeMigrations upgrade the datastore schema, storeMigrations[i] takes version i to i+1.
+// The func a migration returns, if any, runs once the new version is saved. Only ever append
+// to this list
+var storeMigrations = []func(data *storeData) (saved func() error, err error){
+	migrateCreateBuckets,
+	migrateImportTimers,
+	migrateExportAudit,
+}
+
+// AuditRecord is who ran what and when, one per line in the audit log
+type AuditRecord struct {
+	Time      time.Time `json:"Time"`
+	UserID    string    `json:"UserID"`
+	Username  string    `json:"Username"`
+	ChannelID string    `json:"ChannelID"`
+	Command   string    `json:"Command"`
+}
+
+func storePath() string {
+	if configuration.StorePath != "" {
+		return configuration.StorePath
+	}
+	return defaultStorePath
+}
+
+func auditPath() string {
+	if configuration.AuditPath != "" {
+		return configuration.AuditPath
+	}
+	return defaultAuditPath
+}
+
+// openStore loads the datastore at path, creating it if needed, and runs any pending migrations
+func openStore(path string) (*Store, error) {
+	l := LogInit("openStore-store.go")
+	defer l.End()
+	st := &Store{path: path}
+	if err := st.load(); err != nil {
+		return nil, err
+	}
+	if st.data.SchemaVersion > len(storeMigrations) {
+		return nil, fmt.Errorf("datastore %s is schema version %d but we only know version %d", path, st.data.SchemaVersion, len(storeMigrations))
+	}
+	for st.data.SchemaVersion < len(storeMigrations) {
+		l.InfoF("Migrating datastore from version %d", st.data.SchemaVersion)
+		saved, err := storeMigrations[st.data.SchemaVersion](&st.data)
+		if err != nil {
+			return nil, fmt.Errorf("datastore migration %d failed: %v", st.data.SchemaVersion+1, err)
+		}
+		st.data.SchemaVersion++
+		if err := st.save(); err != nil {
+			return nil, err
+		}
+		if saved != nil {
+			if err := saved(); err != nil { // the datastore is migrated, only the cleanup is left undone
+				l.ErrorF("Unable to finish datastore migration %d: %s", st.data.SchemaVersion, err.Error())
+			}
+		}
+	}
+	return st, nil
+}
+
+// load reads the datastore from disk, replacing anything in memory
+func (st *Store) load() error {
+	st.data = storeData{}
+	b, err := ioutil.ReadFile(st.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &st.data); err != nil {
+			return fmt.Errorf("datastore %s is corrupt: %v", st.path, err)
+		}
+	}
+	if st.data.Sequences == nil {
+		st.data.Sequences = make(map[string]int)
+	}
+	if st.data.Buckets == nil {
+		st.data.Buckets = make(map[string]map[string]json.RawMessage)
+	}
+	return nil
+}
+
+// save writes the datastore to a temp file and swaps it in so a crash can't leave half a file
+func (st *Store) save() error {
+	b, err := json.MarshalIndent(st.data, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := st.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, st.path)
+}
+
+// Update runs fn and saves its changes, if fn or the save fails nothing it did is kept
+func (st *Store) Update(fn func(tx *StoreTx) error) error {
+	l := LogInit("Update-store.go")
+	defer l.End()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	err := fn(&StoreTx{data: &st.data})
+	if err == nil {
+		if err = st.save(); err != nil {
+			l.ErrorF("Unable to save datastore: %s", err.Error())
+		}
+	}
+	if err != nil {
+		if loadErr := st.load(); loadErr != nil {
+			l.ErrorF("Unable to roll back datastore: %s", loadErr.Error())
+		}
+	}
+	return err
+}
+
+// View runs fn without saving, fn must not change anything
+func (st *Store) View(fn func(tx *StoreTx) error) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return fn(&StoreTx{data: &st.data})
+}
+
+// Get decodes bucket/key into v, returning false if it doesn't exist
+func (st *Store) Get(bucket, key string, v interface{}) (found bool, err error) {
+	err = st.View(func(tx *StoreTx) error {
+		found, err = tx.Get(bucket, key, v)
+		return err
+	})
+	return found, err
+}
+
+// Put saves v as bucket/key
+func (st *Store) Put(bucket, key string, v interface{}) error {
+	return st.Update(func(tx *StoreTx) error {
+		return tx.Put(bucket, key, v)
+	})
+}
+
+// Delete removes bucket/key
+func (st *Store) Delete(bucket, key string) error {
+	return st.Update(func(tx *StoreTx) error {
+		tx.Delete(bucket, key)
+		return nil
+	})
+}
+
+// ForEach calls fn for every document in bucket in key order
+func (st *Store) ForEach(bucket string, fn func(key string, raw json.RawMessage) error) error {
+	return st.View(func(tx *StoreTx) error {
+		return tx.ForEach(bucket, fn)
+	})
+}
+
+// Get decodes bucket/key into v, returning false if it doesn't exist
+func (tx *StoreTx) Get(bucket, key string, v interface{}) (bool, error) {
+	raw, ok := tx.data.Buckets[bucket][key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Put saves v as bucket/key
+func (tx *StoreTx) Put(bucket, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if tx.data.Buckets[bucket] == nil {
+		tx.data.Buckets[bucket] = make(map[string]json.RawMessage)
+	}
+	tx.data.Buckets[bucket][key] = raw
+	return nil
+}
+
+// Delete removes bucket/key
+func (tx *StoreTx) Delete(bucket, key string) {
+	delete(tx.data.Buckets[bucket], key)
+}
+
+// ForEach calls fn for every document in bucket in key order, numeric keys sort numerically
+func (tx *StoreTx) ForEach(bucket string, fn func(key string, raw json.RawMessage) error) error {
+	keys := make([]string, 0, len(tx.data.Buckets[bucket]))
+	for key := range tx.data.Buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		if err := fn(key, tx.data.Buckets[bucket][key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextID hands out the next id for bucket
+func (tx *StoreTx) NextID(bucket string) int {
+	tx.data.Sequences[bucket]++
+	return tx.data.Sequences[bucket]
+}
+
+// audit records a command in the audit log. The log is only ever appended to so it can
+// grow without slowing down the datastore
+func audit(m *discordgo.MessageCreate, command string) {
+	l := LogInit("audit-store.go")
+	defer l.End()
+	record := AuditRecord{
+		Time:      time.Now(),
+		UserID:    m.Author.ID,
+		Username:  m.Author.Username,
+		ChannelID: m.ChannelID,
+		Command:   command,
+	}
+	if err := appendAudit(record); err != nil {
+		l.ErrorF("Unable to audit %s by %s: %s", command, m.Author.Username, err.Error())
+	}
+}
+
+// appendAudit writes records to the end of the audit log as JSON lines
+func appendAudit(records ...AuditRecord) error {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	file, err := os.OpenFile(auditPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// migrateCreateBuckets is schema version 1
+func migrateCreateBuckets(data *storeData) (func() error, error) {
+	for _, bucket := range []string{bucketAudit, bucketTimers} {
+		if data.Buckets[bucket] == nil {
+			data.Buckets[bucket] = make(map[string]json.RawMessage)
+		}
+	}
+	return nil, nil
+}
+
+// migrateImportTimers is schema version 2, it moves raid timers out of timers.json. The file
+// is renamed once the imported timers are saved
+func migrateImportTimers(data *storeData) (func() error, error) {
+	l := LogInit("migrateImportTimers-store.go")
+	defer l.End()
+	path := configuration.TimersPath
+	if path == "" {
+		path = defaultTimersPath
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	timers := make(map[string]RaidTimer)
+	if err := json.Unmarshal(b, &timers); err != nil {
+		return nil, err
+	}
+	tx := &StoreTx{data: data}
+	for id, timer := range timers {
+		if err := tx.Put(bucketTimers, id, timer); err != nil {
+			return nil, err
+		}
+	}
+	l.InfoF("Imported %d raid timers from %s", len(timers), path)
+	return func() error { return os.Rename(path, path+".migrated") }, nil
+}
+
+// migrateExportAudit is schema version 3, it moves the audit bucket to the audit log. Records
+// already in the log are skipped, so a migration that fails to save can run again
+func migrateExportAudit(data *storeData) (func() error, error) {
+	l := LogInit("migrateExportAudit-store.go")
+	defer l.End()
+	logged, err := auditLines()
+	if err != nil {
+		return nil, err
+	}
+	var records []AuditRecord
+	err = (&StoreTx{data: data}).ForEach(bucketAudit, func(key string, raw json.RawMessage) error {
+		var record AuditRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if !logged[string(line)] {
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := appendAudit(records...); err != nil {
+		return nil, err
+	}
+	delete(data.Buckets, bucketAudit)
+	delete(data.Sequences, bucketAudit)
+	l.InfoF("Moved %d audit records to %s", len(records), auditPath())
+	return nil, nil
+}
+
+// auditLines returns every line in the audit log
+func auditLines() (map[string]bool, error) {
+	lines := make(map[string]bool)
+	b, err := ioutil.ReadFile(auditPath())
+	if os.IsNotExist(err) {
+		return lines, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		lines[line] = true
+	}
+	return lines, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateExportAuditRerun(t *testing.T) {
+	configuration.AuditPath = filepath.Join(t.TempDir(), "audit.log")
+	defer func() { configuration.AuditPath = "" }()
+	bucket := func() *storeData {
+		data := &storeData{Sequences: map[string]int{}, Buckets: map[string]map[string]json.RawMessage{}}
+		tx := &StoreTx{data: data}
+		for i, command := range []string{"!dkp", "!bid cloak 10", "!dkp"} {
+			record := AuditRecord{Time: time.Date(2021, 1, 2, 3, 4, i, 5, time.UTC), UserID: "1", Username: "Mortimus", Command: command}
+			if err := tx.Put(bucketAudit, strconv.Itoa(i+1), record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return data
+	}
+	for run := 1; run <= 2; run++ { // the second run is a migration whose save failed, run again
+		data := bucket()
+		if _, err := migrateExportAudit(data); err != nil {
+			t.Fatal(err)
+		}
+		if data.Buckets[bucketAudit] != nil {
+			t.Errorf("run %d left the audit bucket behind", run)
+		}
+	}
+	b, err := ioutil.ReadFile(configuration.AuditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(b), "\n"); lines != 3 {
+		t.Errorf("audit log has %d lines, want 3:\n%s", lines, b)
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultTimersPath is where timers were saved before the datastore
+const defaultTimersPath = "timers.json"
+
+// timerCheckInterval is how often we look for spawn windows that have opened
+const timerCheckInterval = time.Minute
+
+// timerExpiry is how long after a window closes we keep tracking the timer
+const timerExpiry = 24 * time.Hour
+
+// RaidTimer tracks the respawn window of a killed raid target
+type RaidTimer struct {
+	NPCID       int       `json:"NPCID"`
+	Name        string    `json:"Name"`
+	Zone        string    `json:"Zone"`
+	KilledAt    time.Time `json:"KilledAt"`
+	WindowOpen  time.Time `json:"WindowOpen"`
+	WindowClose time.Time `json:"WindowClose"`
+	ReportedBy  string    `json:"ReportedBy"`
+	Alerted     bool      `json:"Alerted"` // have we announced the window opening
+}
+
+// ReportKill records a raid target kill and starts tracking its respawn window
+func ReportKill(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("ReportKill-timers.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Killed command ran without a mob: %s", message)
+		return ""
+	}
+	args := message[1:]
+	killedAt := time.Now()
+	if len(args) > 1 {
+		if t, ok := parseKillTime(args[len(args)-1], killedAt); ok {
+			killedAt = t
+			args = args[:len(args)-1]
+		}
+	}
+	db, err := getDB()
+	if err != nil {
+		return err.Error()
+	}
+	npc, choices, err := resolveNPC(strings.Join(args, " "), db)
+	if err != nil {
+		return err.Error()
+	}
+	if choices != "" {
+		return choices
+	}
+	spawns, err := getSpawnsByNPC(npc.id, db)
+	if err != nil {
+		l.ErrorF("Error looking up spawns for %d: %s", npc.id, err.Error())
+		return gameDBFailed(err).Error()
+	}
+	if len(spawns) == 0 {
+		return fmt.Sprintf("%s has no spawn points, I can't track its respawn", npc.name)
+	}
+	spawn := spawns[0]
+	for _, sp := range spawns[1:] {
+		if sp.respawn > spawn.respawn {
+			spawn = sp
+		}
+	}
+	timer := &RaidTimer{
+		NPCID:       npc.id,
+		Name:        npc.name,
+		Zone:        spawn.zone,
+		KilledAt:    killedAt,
+		WindowOpen:  killedAt.Add(spawn.respawn - spawn.variance/2), // EQEmu rolls the respawn within half the variance either way
+		WindowClose: killedAt.Add(spawn.respawn + spawn.variance/2),
+		ReportedBy:  m.Author.Username,
+	}
+	if err := store.Put(bucketTimers, strconv.Itoa(npc.id), timer); err != nil {
+		l.ErrorF("Error saving timer for %s: %s", npc.name, err.Error())
+		return "Unable to save the timer, please try again"
+	}
+	l.InfoF("%s reported %s killed at %v", m.Author.Username, npc.name, killedAt)
+	return fmt.Sprintf("%s killed at %s, window opens %s and closes %s", npc.name, killedAt.Format(timerFormat), timer.WindowOpen.Format(timerFormat), timer.WindowClose.Format(timerFormat))
+}
+
+// timerFormat is how we show window times to users
+const timerFormat = "Mon Jan 2 3:04 PM MST"
+
+// parseKillTime understands 15:04, 3:04pm or a duration ago like 45m
+func parseKillTime(input string, now time.Time) (time.Time, bool) {
+	if d, err := time.ParseDuration(input); err == nil && d > 0 {
+		return now.Add(-d), true
+	}
+	for _, layout := range []string{"15:04", "3:04pm", "3:04PM"} {
+		t, err := time.ParseInLocation(layout, input, now.Location())
+		if err != nil {
+			continue
+		}
+		killed := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if killed.After(now) { // must have been yesterday
+			killed = killed.AddDate(0, 0, -1)
+		}
+		return killed, true
+	}
+	return time.Time{}, false
+}
+
+// ListTimers shows every tracked raid target and how long until its window opens
+func ListTimers(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response string) {
+	l := LogInit("ListTimers-timers.go")
+	defer l.End()
+	timers, err := loadTimers()
+	if err != nil {
+		l.ErrorF("Error loading timers: %s", err.Error())
+		return "Unable to load timers at this time"
+	}
+	if len(timers) == 0 {
+		return "No raid targets are being tracked"
+	}
+	sort.Slice(timers, func(i, j int) bool { return timers[i].WindowOpen.Before(timers[j].WindowOpen) })
+	now := time.Now()
+	for _, timer := range timers {
+		var status string
+		switch {
+		case now.Before(timer.WindowOpen):
+			status = fmt.Sprintf("opens in %v", timer.WindowOpen.Sub(now).Round(time.Minute))
+		case now.Before(timer.WindowClose):
+			status = fmt.Sprintf("OPEN, closes in %v", timer.WindowClose.Sub(now).Round(time.Minute))
+		default:
+			status = fmt.Sprintf("closed %v ago", now.Sub(timer.WindowClose).Round(time.Minute))
+		}
+		response = fmt.Sprintf("%s%s (%s):\t%s\n", response, timer.Name, timer.Zone, status)
+	}
+	return response
+}
+
+// watchRaidTimers announces spawn windows as they open, it never returns
+func watchRaidTimers(s *discordgo.Session) {
+	l := LogInit("watchRaidTimers-timers.go")
+	defer l.End()
+	ticker := time.NewTicker(timerCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		checkRaidTimers(s)
+	}
+}
+
+func checkRaidTimers(s *discordgo.Session) {
+	l := LogInit("checkRaidTimers-timers.go")
+	defer l.End()
+	if configuration.TimerChannelID == "" { // nowhere to announce, leave the timers for when there is
+		return
+	}
+	now := time.Now()
+	// due says whether a timer has expired or its window just opened
+	due := func(timer RaidTimer) (expired, open bool) {
+		if now.Sub(timer.WindowClose) > timerExpiry {
+			return true, false
+		}
+		return false, !timer.Alerted && !now.Before(timer.WindowOpen)
+	}
+	expiredAny := false
+	opened := make(map[string]RaidTimer)
+	err := store.View(func(tx *StoreTx) error {
+		return tx.ForEach(bucketTimers, func(key string, raw json.RawMessage) error {
+			var timer RaidTimer
+			if err := json.Unmarshal(raw, &timer); err != nil {
+				return err
+			}
+			expired, open := due(timer)
+			expiredAny = expiredAny || expired
+			if open {
+				opened[key] = timer
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		l.ErrorF("Error checking timers: %s", err.Error())
+		return
+	}
+	// announce before marking alerted, a window whose announcement failed is tried again next check
+	announced := make(map[string]time.Time)
+	for key, timer := range opened {
+		msg := fmt.Sprintf("The spawn window for %s in %s is now open, it closes %s", timer.Name, timer.Zone, timer.WindowClose.Format(timerFormat))
+		if _, err := s.ChannelMessageSend(configuration.TimerChannelID, msg); err != nil {
+			l.ErrorF("Error announcing window for %s: %s", timer.Name, err.Error())
+			continue
+		}
+		announced[key] = timer.WindowOpen
+	}
+	if !expiredAny && len(announced) == 0 { // nothing to save, don't rewrite the datastore
+		return
+	}
+	err = store.Update(func(tx *StoreTx) error {
+		return tx.ForEach(bucketTimers, func(key string, raw json.RawMessage) error {
+			var timer RaidTimer
+			if err := json.Unmarshal(raw, &timer); err != nil {
+				return err
+			}
+			if expired, _ := due(timer); expired {
+				l.InfoF("No longer tracking %s", timer.Name)
+				tx.Delete(bucketTimers, key)
+				return nil
+			}
+			windowOpen, ok := announced[key]
+			if !ok || !timer.WindowOpen.Equal(windowOpen) { // a new kill since the announcement is announced itself
+				return nil
+			}
+			timer.Alerted = true
+			return tx.Put(bucketTimers, key, timer)
+		})
+	})
+	if err != nil {
+		l.ErrorF("Error checking timers: %s", err.Error())
+	}
+}
+
+// loadTimers returns every tracked raid timer
+func loadTimers() ([]RaidTimer, error) {
+	var timers []RaidTimer
+	err := store.ForEach(bucketTimers, func(key string, raw json.RawMessage) error {
+		var timer RaidTimer
+		if err := json.Unmarshal(raw, &timer); err != nil {
+			return err
+		}
+		timers = append(timers, timer)
+		return nil
+	})
+	return timers, err
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// specialAbilityNames maps EQEmu special_abilities codes to readable traits
+var specialAbilityNames = map[int]string{
+	1:  "Summon",
+	2:  "Enrage",
+	3:  "Rampage",
+	4:  "Area Rampage",
+	5:  "Flurry",
+	6:  "Triple Attack",
+	7:  "Quad Attack",
+	8:  "Dual Wield",
+	9:  "Bane Attack",
+	10: "Magical Attack",
+	11: "Ranged Attack",
+	12: "Immune to Slow",
+	13: "Immune to Mez",
+	14: "Immune to Charm",
+	15: "Immune to Stun",
+	16: "Immune to Snare",
+	17: "Unfearable",
+	18: "Immune to Dispel",
+	19: "Immune to Melee",
+	20: "Immune to Magic",
+	21: "Never Flees",
+	22: "Immune to Non-Bane Melee",
+	23: "Immune to Non-Magical Melee",
+	24: "Never Aggros",
+	25: "Immune to Aggro",
+	26: "Immune to Ranged Spells",
+	27: "Sees Through Feign Death",
+	28: "Immune to Taunt",
+	29: "Tunnel Vision",
+	30: "Does Not Heal Friends",
+	31: "Immune to Lull",
+	32: "Leashed",
+	33: "Tethered",
+	34: "Destructible Object",
+	35: "Immune to Player Damage",
+	36: "Always Flees",
+	37: "Flee Percent",
+	38: "Allows Beneficial Spells",
+	39: "Cannot Melee",
+	40: "Chase Distance",
+	41: "Allowed to Tank",
+	42: "Ignores Root Aggro",
+	43: "Casting Resist Modifier",
+	44: "Counter Avoid Damage",
+	45: "Proximity Aggro",
+	46: "Immune to Ranged Attacks",
+	47: "Immune to Player Damage",
+	48: "Immune to NPC Damage",
+	49: "Immune to Player Aggro",
+	50: "Immune to NPC Aggro",
+	51: "Modified Avoid Damage",
+	52: "Immune to Fading Memories",
+	53: "Immune to Open",
+	54: "Immune to Assassinate",
+	55: "Immune to Headshot",
+}
+
+// legacySpecialAttacks maps the old npcspecialattks letters to special_abilities codes
+var legacySpecialAttacks = map[rune]int{
+	'S': 1,
+	'E': 2,
+	'R': 3,
+	'r': 4,
+	'F': 5,
+	'T': 6,
+	'Q': 7,
+	'L': 8,
+	'b': 9,
+	'm': 10,
+	'Y': 11,
+	'U': 12,
+	'M': 13,
+	'C': 14,
+	'N': 15,
+	'I': 16,
+	'D': 17,
+	'K': 18,
+	'A': 19,
+	'B': 20,
+	'f': 21,
+	'O': 22,
+	'W': 23,
+	'H': 24,
+	'G': 25,
+	'g': 26,
+	'd': 27,
+	'i': 28,
+	't': 29,
+	'n': 30,
+	'p': 31,
+}
+
+// decodeSpecialAbilities parses a special_abilities string (code,value,params^code,value...)
+// and returns the codes that are enabled
+func decodeSpecialAbilities(abilities string) []int {
+	l := LogInit("decodeSpecialAbilities-traits.go")
+	defer l.End()
+	var codes []int
+	for _, ability := range strings.Split(abilities, "^") {
+		parts := strings.Split(strings.TrimSpace(ability), ",")
+		if len(parts) < 2 {
+			continue
+		}
+		code, err := strconv.Atoi(parts[0])
+		if err != nil {
+			l.WarnF("Unknown special ability code %s in %s", parts[0], abilities)
+			continue
+		}
+		value, err := strconv.Atoi(parts[1])
+		if err != nil || value == 0 { // a value of 0 means the ability is turned off
+			continue
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// decodeLegacySpecialAttacks converts npcspecialattks letters to special_abilities codes
+func decodeLegacySpecialAttacks(attacks string) []int {
+	l := LogInit("decodeLegacySpecialAttacks-traits.go")
+	defer l.End()
+	var codes []int
+	for _, letter := range attacks {
+		code, ok := legacySpecialAttacks[letter]
+		if !ok {
+			l.WarnF("Unknown special attack letter %c in %s", letter, attacks)
+			continue
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// traits returns the readable names of every special ability the mob has
+func (npc NPC) traits() []string {
+	codes := append(decodeSpecialAbilities(npc.specialAbilities), decodeLegacySpecialAttacks(npc.npcSpecialAttacks)...)
+	sort.Ints(codes)
+	seen := make(map[string]bool)
+	var traits []string
+	for _, code := range codes {
+		name, ok := specialAbilityNames[code]
+		if !ok {
+			name = fmt.Sprintf("Special Ability %d", code)
+		}
+		if !seen[name] {
+			seen[name] = true
+			traits = append(traits, name)
+		}
+	}
+	if npc.seeInvis > 0 {
+		traits = append(traits, "See Invis")
+	}
+	if npc.seeInvisUndead > 0 {
+		traits = append(traits, "See Invis vs Undead")
+	}
+	if npc.seeHide > 0 || npc.seeImprovedHide > 0 {
+		traits = append(traits, "See Hide")
+	}
+	return traits
+}
+
+// traitLine is the mob's traits as a line for mob cards, "" if it has none
+func (npc NPC) traitLine() string {
+	traits := npc.traits()
+	if len(traits) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Traits: %s\n", strings.Join(traits, ", "))
+}
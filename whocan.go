@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// LookupWhoCan lists the raiders who can use an item, highest DKP first, striking out
+// anyone the loot rules make ineligible
+func LookupWhoCan(s *discordgo.Session, m *discordgo.MessageCreate, message []string) (response BotResponse) {
+	l := LogInit("LookupWhoCan-whocan.go")
+	defer l.End()
+	if len(message) < 2 {
+		l.ErrorF("Whocan command ran without an item: %s", message)
+		return BotResponse{}
+	}
+	db, err := getDB()
+	if err != nil {
+		return textResponse(err.Error())
+	}
+	item, choices, err := resolveItem(strings.Join(message[1:], " "), db)
+	if err != nil {
+		return textResponse(err.Error())
+	}
+	if choices != "" {
+		return textResponse(choices)
+	}
+	classes := decodeBitmask(item.classes, eqClasses, "")
+	var players []Player
+	for _, player := range lookupAllPlayer() {
+		if containsString(classes, strings.TrimSpace(strings.ToLower(player.class))) {
+			players = append(players, player)
+		}
+	}
+	title := fmt.Sprintf("%s (%s)", item.name, titleList(decodeBitmask(item.classes, eqClasses, "all")))
+	if len(players) == 0 {
+		return textResponse(title + "\nNobody on the roster can use it\n")
+	}
+	attendance, err := readRaidAttendance()
+	if err != nil {
+		l.ErrorF("Unable to read attendance for whocan: %s", err.Error())
+		return textResponse(err.Error())
+	}
+	sort.Sort(sort.Reverse(byDKP(players)))
+	response.text = title + "\n"
+	var fields []*discordgo.MessageEmbedField
+	for _, player := range players {
+		percent := attendance.percent(player.name)
+		line := fmt.Sprintf("%s(%s %s):\t%d\t%d%% attendance", player.name, player.rank, strings.Title(player.class), player.dkp, percent)
+		field := &discordgo.MessageEmbedField{Name: fmt.Sprintf("%s (%s %s)", player.name, player.rank, strings.Title(player.class)), Value: fmt.Sprintf("%d DKP, %d%% attendance", player.dkp, percent), Inline: true}
+		if reason := lootIneligible(player, attendance); reason != "" {
+			line = fmt.Sprintf("~~%s~~\t%s", line, reason)
+			field.Value = fmt.Sprintf("~~%s~~\n%s", field.Value, reason)
+		}
+		response.text += line + "\n"
+		fields = append(fields, field)
+	}
+	response.text += rosterAge()
+	response.embeds = fieldEmbeds(title, classColor(players...), fields, dataFooter())
+	return response
+}
+
+// lootIneligible returns why the guild rules stop a player winning loot, or "" if they can
+func lootIneligible(player Player, attendance RaidAttendance) string {
+	for _, rank := range configuration.LootIneligibleRanks {
+		if strings.EqualFold(rank, player.rank) {
+			return fmt.Sprintf("(%s can't take loot)", player.rank)
+		}
+	}
+	if !attendance.eligible(player.name) {
+		return fmt.Sprintf("(below %d%% attendance)", configuration.AttendanceThreshold)
+	}
+	return ""
+}